@@ -0,0 +1,154 @@
+// Command muxroutes prints the route table of the handlers registered
+// with mux.RegisterHandler by a package.
+//
+// Registrations are loaded from the files of the package constrained by
+// the muxroutes build tag, which keeps them out of regular builds:
+//
+//	//go:build muxroutes
+//	// +build muxroutes
+//
+//	package app
+//
+//	import "github.com/pnelson/mux"
+//
+//	func init() {
+//		mux.RegisterHandler("app", NewHandler)
+//	}
+//
+// Usage:
+//
+//	muxroutes [-json] [-explain "METHOD URL"] [package]
+//
+// The package defaults to the package in the current directory and must
+// not be a main package. The command generates a temporary program
+// within the current module that imports the package and runs it with
+// the go tool.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var program = template.Must(template.New("main").Parse(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pnelson/mux"
+
+	_ {{.Package}}
+)
+
+func main() {
+	names := mux.RegisteredHandlers()
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "muxroutes: no handlers registered with the muxroutes build tag")
+		os.Exit(1)
+	}
+	out := make(map[string]interface{})
+	for _, name := range names {
+		h := mux.RegisteredHandler(name)
+		var v interface{}
+		if {{.Explain}} != "" {
+			fields := strings.Fields({{.Explain}})
+			if len(fields) != 2 {
+				fmt.Fprintln(os.Stderr, "muxroutes: explain requires \"METHOD URL\"")
+				os.Exit(2)
+			}
+			v = h.Explain(fields[0], fields[1])
+		} else {
+			routes, err := h.Routes()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "muxroutes:", err)
+				os.Exit(1)
+			}
+			v = routes
+		}
+		if {{.JSON}} {
+			out[name] = v
+			continue
+		}
+		if len(names) > 1 {
+			fmt.Printf("# %s\n", name)
+		}
+		fmt.Println(strings.TrimSuffix(fmt.Sprint(v), "\n"))
+	}
+	if {{.JSON}} {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "muxroutes:", err)
+			os.Exit(1)
+		}
+	}
+}
+`))
+
+func main() {
+	asJSON := flag.Bool("json", false, "print JSON")
+	explain := flag.String("explain", "", "explain the route matching \"METHOD URL\"")
+	flag.Parse()
+	pkg := "."
+	if flag.NArg() > 0 {
+		pkg = flag.Arg(0)
+	}
+	err := run(pkg, *asJSON, *explain)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "muxroutes:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates and runs the program printing the routes of the package.
+func run(pkg string, asJSON bool, explain string) error {
+	path, err := importPath(pkg)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp(".", "muxroutes")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	f, err := os.Create(filepath.Join(dir, "main.go"))
+	if err != nil {
+		return err
+	}
+	err = program.Execute(f, map[string]string{
+		"Package": strconv.Quote(path),
+		"Explain": strconv.Quote(explain),
+		"JSON":    strconv.FormatBool(asJSON),
+	})
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("go", "run", "-tags", "muxroutes", "./"+filepath.ToSlash(dir))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// importPath returns the import path of the package.
+func importPath(pkg string) (string, error) {
+	out, err := exec.Command("go", "list", "-tags", "muxroutes", "-f", "{{.ImportPath}}", pkg).Output()
+	if err != nil {
+		return "", fmt.Errorf("go list %s: %w", pkg, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
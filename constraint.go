@@ -0,0 +1,36 @@
+package mux
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// constraints maps the built-in param constraint names to expressions.
+var constraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"alpha": `[a-zA-Z]+`,
+}
+
+// compiled holds the compiled param constraints keyed by expression.
+var compiled sync.Map
+
+// compileConstraint returns the anchored regular expression for
+// the built-in constraint name or custom regular expression.
+func compileConstraint(expr string) (*regexp.Regexp, error) {
+	v, ok := compiled.Load(expr)
+	if ok {
+		return v.(*regexp.Regexp), nil
+	}
+	s, ok := constraints[expr]
+	if !ok {
+		s = expr
+	}
+	re, err := regexp.Compile("^(?:" + s + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("mux: invalid param constraint '%s'", expr)
+	}
+	compiled.Store(expr, re)
+	return re, nil
+}
@@ -2,7 +2,10 @@ package mux
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"golang.org/x/text/language"
 )
@@ -18,10 +21,54 @@ var seq uint64
 
 // requestContext represents the mux-specific request context.
 type requestContext struct {
+	h      *Handler
+	root   *Handler
+	parent *requestContext
 	seq    uint64
 	route  *Route
 	params Params
+	pooled bool
 	locale language.Tag
+	err    error
+	path   string
+}
+
+// merge merges params into the request context params. The params
+// are adopted if the request context has none, reporting true.
+func (rc *requestContext) merge(params Params) bool {
+	if len(rc.params) == 0 {
+		rc.params = params
+		return true
+	}
+	for k, v := range params {
+		rc.params[k] = v
+	}
+	return false
+}
+
+// propagate sets the matched route and error of a mounted request
+// on the parent request context for the observer of the parent.
+func (rc *requestContext) propagate() {
+	if rc.route != nil {
+		rc.parent.route = rc.route
+	}
+	if rc.err != nil {
+		rc.parent.err = rc.err
+	}
+}
+
+// mountPrefix returns the escaped path prefix removed from the
+// request URL by the mounts of parent handlers.
+func mountPrefix(req *http.Request) string {
+	rc, ok := req.Context().Value(requestContextKey).(*requestContext)
+	if !ok {
+		return ""
+	}
+	p := req.URL.EscapedPath()
+	if !strings.HasSuffix(rc.path, p) {
+		return ""
+	}
+	return rc.path[:len(rc.path)-len(p)]
 }
 
 func getContext(req *http.Request) *requestContext {
@@ -59,8 +106,24 @@ func SetLocale(req *http.Request, tag language.Tag) {
 	rc.locale = tag
 }
 
-// Param returns the named parameter.
+// Param returns the named parameter, unescaped.
 func Param(req *http.Request, name string) string {
+	return unescape(RawParam(req, name))
+}
+
+// RawParam returns the named parameter as matched in the escaped path.
+func RawParam(req *http.Request, name string) string {
 	rc := getContext(req)
 	return rc.params[name]
 }
+
+// URL returns the URL for the named route with the query built by the
+// root Handler serving the request. Routes of mounted handlers are named
+// under the mount route name. See Handler.BuildURL for more details.
+func URL(req *http.Request, name string, params Params, query url.Values) (*url.URL, error) {
+	rc := getContext(req)
+	if rc.root == nil {
+		return nil, errors.New("mux: request is not served by a Handler")
+	}
+	return rc.root.BuildURL(name, params, query)
+}
@@ -1,6 +1,7 @@
 package mux
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -149,3 +150,27 @@ func TestMatch(t *testing.T) {
 		t.Fatalf("match\nhave %q\nwant %q", have, want)
 	}
 }
+
+func TestParam(t *testing.T) {
+	h := New()
+	h.Add("/:name/*", func(w http.ResponseWriter, req *http.Request) error {
+		_, err := fmt.Fprintf(w, "%s %s %s %s", Param(req, "name"), RawParam(req, "name"), Param(req, "*"), RawParam(req, "*"))
+		return err
+	}, WithMethod(http.MethodGet))
+	server := httptest.NewServer(h)
+	defer server.Close()
+	client := server.Client()
+	resp, err := client.Get(server.URL + "/caf%C3%A9/a%2Fb/c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	have, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "caf\u00e9 caf%C3%A9 a/b/c a%2Fb/c"
+	if string(have) != want {
+		t.Fatalf("param\nhave %q\nwant %q", have, want)
+	}
+}
@@ -0,0 +1,264 @@
+package mux
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CSVMarshaler represents a view that encodes itself as CSV records.
+// The first record is the header row.
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+// ErrCSVType indicates that a view or form type is not supported by CSV.
+var ErrCSVType = errors.New("mux: csv requires a struct or a slice of structs")
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// NewCSVEncoder returns an Encoder of the text/csv media type. Views
+// must be a struct, a slice or array of structs, or implement
+// CSVMarshaler. The header row is named by the csv struct tags of the
+// exported fields, or the field names if not tagged. Fields tagged
+// with "-" are omitted.
+func NewCSVEncoder() Encoder {
+	return &csvEncoder{}
+}
+
+type csvEncoder struct{}
+
+func (*csvEncoder) Encode(w io.Writer, view Viewable) error {
+	m, ok := view.(CSVMarshaler)
+	if ok {
+		records, err := m.MarshalCSV()
+		if err != nil {
+			return err
+		}
+		cw := csv.NewWriter(w)
+		return cw.WriteAll(records)
+	}
+	v := reflect.Indirect(reflect.ValueOf(view))
+	rows := []reflect.Value{v}
+	if isList(v) {
+		rows = make([]reflect.Value, v.Len())
+		for i := range rows {
+			rows[i] = reflect.Indirect(v.Index(i))
+		}
+		v = reflect.Zero(derefType(v.Type().Elem()))
+	}
+	if v.Kind() != reflect.Struct {
+		return ErrCSVType
+	}
+	fields := csvFields(v.Type())
+	cw := csv.NewWriter(w)
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		record[i] = f.name
+	}
+	err := cw.Write(record)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		for i, f := range fields {
+			if !row.IsValid() {
+				record[i] = ""
+				continue
+			}
+			record[i], err = formatCSV(row.FieldByIndex(f.index))
+			if err != nil {
+				return err
+			}
+		}
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (*csvEncoder) Headers() http.Header {
+	return http.Header{"Content-Type": []string{"text/csv; charset=utf-8; header=present"}}
+}
+
+// NewCSVDecoder returns a Decoder of the text/csv media type. Forms
+// must be a pointer to a slice of structs, each decoded from a record,
+// or a pointer to a struct, decoded from the first record. The columns
+// are mapped to fields by the header row as described by NewCSVEncoder.
+// Unknown columns are ignored.
+func NewCSVDecoder() Decoder {
+	return &csvDecoder{}
+}
+
+type csvDecoder struct{}
+
+// Decode implements the Decoder interface.
+func (*csvDecoder) Decode(req *http.Request, form Form) error {
+	defer req.Body.Close()
+	v := reflect.ValueOf(form)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrCSVType
+	}
+	v = v.Elem()
+	t := v.Type()
+	if v.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if derefType(t).Kind() != reflect.Struct {
+		return ErrCSVType
+	}
+	cr := csv.NewReader(req.Body)
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	fields := make([]*csvField, len(header))
+	for _, f := range csvFields(derefType(t)) {
+		for i, name := range header {
+			if name == f.name {
+				f := f
+				fields[i] = &f
+			}
+		}
+	}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			if v.Kind() == reflect.Slice {
+				return nil
+			}
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		row := reflect.New(derefType(t)).Elem()
+		for i, f := range fields {
+			if f == nil {
+				continue
+			}
+			err = parseCSV(row.FieldByIndex(f.index), record[i])
+			if err != nil {
+				return fmt.Errorf("mux: csv column %s: %w", f.name, err)
+			}
+		}
+		if t.Kind() == reflect.Ptr {
+			row = row.Addr()
+		}
+		if v.Kind() != reflect.Slice {
+			v.Set(row)
+			return nil
+		}
+		v.Set(reflect.Append(v, row))
+	}
+}
+
+// csvField represents a CSV column of a struct field.
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvFields returns the CSV columns of the struct type.
+func csvFields(t reflect.Type) []csvField {
+	var fields []csvField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("csv")
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, csvField{name: name, index: f.Index})
+	}
+	return fields
+}
+
+// derefType returns the type pointed to by pointer types.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// formatCSV returns the CSV value of the field.
+func formatCSV(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+// parseCSV sets the field to the CSV value.
+func parseCSV(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if s == "" {
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		v.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+		return err
+	}
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
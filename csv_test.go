@@ -0,0 +1,123 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCSVRow struct {
+	ID       int       `csv:"id"`
+	Name     string    `csv:"name"`
+	Score    float64   `csv:"score"`
+	Active   bool      `csv:"active"`
+	Created  time.Time `csv:"created"`
+	Parent   *int      `csv:"parent"`
+	Ignored  string    `csv:"-"`
+	Untagged string
+	private  string
+}
+
+type testCSVForm []testCSVRow
+
+func (f testCSVForm) Validate() error {
+	return nil
+}
+
+func (f *testCSVRow) Validate() error {
+	return nil
+}
+
+const testCSV = "id,name,score,active,created,parent,Untagged\n" +
+	"1,\"Doe, Jane\",1.5,true,2006-01-02T15:04:05Z,,x\n" +
+	"2,John,0,false,0001-01-01T00:00:00Z,1,\n"
+
+func TestCSVEncoder(t *testing.T) {
+	parent := 1
+	created := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	view := []testCSVRow{
+		{ID: 1, Name: "Doe, Jane", Score: 1.5, Active: true, Created: created, Ignored: "ignored", Untagged: "x"},
+		{ID: 2, Name: "John", Parent: &parent},
+	}
+	var b strings.Builder
+	err := NewCSVEncoder().Encode(&b, view)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "body", b.String(), testCSV)
+	b.Reset()
+	err = NewCSVEncoder().Encode(&b, []*testCSVRow{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "body", b.String(), "id,name,score,active,created,parent,Untagged\n")
+	err = NewCSVEncoder().Encode(&b, []int{1})
+	if err != ErrCSVType {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCSVDecoder(t *testing.T) {
+	h := New(WithDecoder(NewContentTypeDecoder(map[string]Decoder{
+		"text/csv": NewCSVDecoder(),
+	})))
+	req := newTestRequest(http.MethodPost, "/", strings.NewReader(testCSV))
+	req.Header.Set("Content-Type", "text/csv")
+	var form testCSVForm
+	err := h.Decode(req, &form)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertInt(t, "len", len(form), 2)
+	assertString(t, "name", form[0].Name, "Doe, Jane")
+	assertString(t, "created", form[0].Created.Format(time.RFC3339), "2006-01-02T15:04:05Z")
+	if form[0].Parent != nil || form[1].Parent == nil || *form[1].Parent != 1 {
+		t.Fatalf("unexpected parents: %v %v", form[0].Parent, form[1].Parent)
+	}
+	req = newTestRequest(http.MethodPost, "/", strings.NewReader(testCSV))
+	req.Header.Set("Content-Type", "text/csv")
+	var row testCSVRow
+	err = h.Decode(req, &row)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertInt(t, "id", row.ID, 1)
+	req = newTestRequest(http.MethodPost, "/", strings.NewReader("id\ninvalid\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	err = h.Decode(req, &form)
+	if err != ErrDecodeRequestData {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCSVErrorView(t *testing.T) {
+	h := New(WithEncoder(NewAcceptEncoder(map[string]Encoder{
+		"text/csv": NewCSVEncoder(),
+	})))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/csv")
+	req.Header.Set("X-Request-ID", "test")
+	h.ServeHTTP(w, req)
+	resp := w.Result()
+	assertStatus(t, resp, http.StatusNotFound)
+	assertHeader(t, resp, "Content-Type", "text/csv; charset=utf-8; header=present")
+	assertString(t, "body", w.Body.String(), "code,title,message,request_id\n404,Not Found,,test\n")
+}
+
+func TestCSVProblemView(t *testing.T) {
+	view := ProblemView{
+		Type:       "about:blank",
+		Title:      "Method Not Allowed",
+		Status:     http.StatusMethodNotAllowed,
+		Extensions: map[string]interface{}{"allow": []string{"GET", "POST"}, "request_id": "test"},
+	}
+	var b strings.Builder
+	err := NewCSVEncoder().Encode(&b, view)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "body", b.String(), "allow,request_id,status,title,type\n\"GET,POST\",test,405,Method Not Allowed,about:blank\n")
+}
@@ -81,6 +81,11 @@ func NewContentTypeDecoder(decoders map[string]Decoder) DecoderFunc {
 	return fn
 }
 
+// NewJSONDecoder returns a Decoder of the application/json media type.
+func NewJSONDecoder() Decoder {
+	return &jsonDecoder{}
+}
+
 type jsonDecoder struct{}
 
 // Decode implements the Decoder interface.
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"mime"
 	"net/http"
 	"strings"
 )
@@ -28,18 +27,30 @@ type EncoderFunc func(req *http.Request) (Encoder, error)
 var ErrEncodeMatch = errors.New("mux: no encoder matched request")
 
 // Encode encodes the view and responds to the request.
+// Accept is added to the Vary header of the response.
 func (h *Handler) Encode(w http.ResponseWriter, req *http.Request, view Viewable, code int) error {
-	e, err := h.encoder(req)
+	return h.encode(h.encoder, w, req, view, code)
+}
+
+// encode encodes the view with the negotiated encoder and responds to the request.
+func (h *Handler) encode(fn EncoderFunc, w http.ResponseWriter, req *http.Request, view Viewable, code int) error {
+	e, err := fn(req)
 	if err != nil {
 		return err
 	}
 	b := h.pool.Get()
 	defer h.pool.Put(b)
-	err = e.Encode(b, view)
+	re, ok := e.(RequestEncoder)
+	if ok {
+		err = re.EncodeRequest(b, req, view)
+	} else {
+		err = e.Encode(b, view)
+	}
 	if err != nil {
 		return err
 	}
 	headers := w.Header()
+	addVary(headers, "Accept")
 	for k, vs := range e.Headers() {
 		for _, v := range vs {
 			headers.Add(k, v)
@@ -50,33 +61,59 @@ func (h *Handler) Encode(w http.ResponseWriter, req *http.Request, view Viewable
 	return err
 }
 
-// NewAcceptEncoder returns an EncoderFunc that returns the
-// first Encoder negotiated from the request Accept header.
-func NewAcceptEncoder(encoders map[string]Encoder) EncoderFunc {
+// NewAcceptEncoder returns an EncoderFunc that negotiates an Encoder
+// from the request Accept header as defined by RFC 9110. The media type
+// with the highest quality is chosen, where the quality of a media type
+// is that of the most specific matching media range. Media types may
+// have parameters, such as application/vnd.api+json; version=2, matched
+// by media ranges with the same parameter values. Media types of the
+// same quality are chosen in order of the preferred media types, followed
+// by the remaining media types in lexical order. Malformed entries of the
+// Accept header are ignored. The Encoder of the empty media type, if any,
+// is returned for requests without an Accept header. Otherwise, such
+// requests accept any media type.
+func NewAcceptEncoder(encoders map[string]Encoder, preference ...string) EncoderFunc {
+	mediaTypes := make([]string, 0, len(encoders))
+	for k := range encoders {
+		mediaTypes = append(mediaTypes, k)
+	}
+	offers := newOffers(mediaTypes, preference)
 	fn := func(req *http.Request) (Encoder, error) {
 		accept := req.Header.Get("Accept")
-		// mime.ParseMediaType returns an unexported error for
-		// the empty string, so we short-circuit it here.
-		// An exact match is great, too.
-		e, ok := encoders[accept]
-		if ok {
-			return e, nil
-		}
-		for _, t := range strings.Split(accept, ",") {
-			mediaType, _, err := mime.ParseMediaType(t)
-			if err != nil {
-				return nil, err
-			}
-			e, ok := encoders[mediaType]
+		if accept == "" {
+			e, ok := encoders[""]
 			if ok {
 				return e, nil
 			}
+			accept = "*/*"
+		}
+		key, ok := negotiate(parseAccept(accept), offers)
+		if !ok {
+			return nil, ErrEncodeMatch
 		}
-		return nil, ErrEncodeMatch
+		return encoders[key], nil
 	}
 	return fn
 }
 
+// addVary adds the header name to the Vary header if not present.
+func addVary(headers http.Header, name string) {
+	for _, v := range headers.Values("Vary") {
+		for _, s := range strings.Split(v, ",") {
+			s = strings.TrimSpace(s)
+			if s == "*" || strings.EqualFold(s, name) {
+				return
+			}
+		}
+	}
+	headers.Add("Vary", name)
+}
+
+// NewJSONEncoder returns an Encoder of the application/json media type.
+func NewJSONEncoder() Encoder {
+	return &jsonEncoder{}
+}
+
 type jsonEncoder struct{}
 
 func (*jsonEncoder) Encode(w io.Writer, view Viewable) error {
@@ -2,6 +2,7 @@ package mux
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -40,3 +41,100 @@ func TestErrEncodeMatch(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+type testEncoder string
+
+func (e testEncoder) Encode(w io.Writer, view Viewable) error {
+	_, err := io.WriteString(w, string(e))
+	return err
+}
+
+func (e testEncoder) Headers() http.Header {
+	return http.Header{"Content-Type": []string{string(e)}}
+}
+
+func TestNewAcceptEncoder(t *testing.T) {
+	fn := NewAcceptEncoder(map[string]Encoder{
+		"application/json":                     testEncoder("application/json"),
+		"application/xml":                      testEncoder("application/xml"),
+		"text/html":                            testEncoder("text/html"),
+		"application/vnd.test+json; version=1": testEncoder("v1"),
+		"application/vnd.test+json; version=2": testEncoder("v2"),
+	}, "application/json", "text/html")
+	var tests = []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/html;q=0.1, application/json", "application/json"},
+		{"application/json;q=0.5, text/html", "text/html"},
+		{"application/xml, application/json", "application/json"},
+		{"application/*;q=0.8, application/xml", "application/xml"},
+		{"text/*, application/json;q=0.9", "text/html"},
+		{"*/*;q=0.1, application/json;q=0", "text/html"},
+		{"*/*, text/html;q=0, application/json;q=0", "v1"},
+		{"application/vnd.test+json;version=2", "v2"},
+		{"application/vnd.test+json;version=2;q=0.5, application/vnd.test+json;q=0.4", "v2"},
+		{"application/vnd.test+json;version=2;q=0.4, application/vnd.test+json;q=0.5", "v1"},
+		{"text/html;;, application/xml", "application/xml"},
+		{"application/json;q=2, application/xml", "application/xml"},
+		{"invalid, text/html", "text/html"},
+	}
+	for _, tt := range tests {
+		req := newTestRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		e, err := fn(req)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.accept, err)
+		}
+		assertString(t, tt.accept, string(e.(testEncoder)), tt.want)
+	}
+}
+
+func TestNewAcceptEncoderNoMatch(t *testing.T) {
+	fn := NewAcceptEncoder(map[string]Encoder{
+		"application/json": testEncoder("application/json"),
+	})
+	var tests = []string{
+		"text/html",
+		"application/json;q=0",
+		"*/*;q=0",
+		"application/json;version=2",
+		"invalid",
+		"application/json;q=invalid",
+	}
+	for _, accept := range tests {
+		req := newTestRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", accept)
+		_, err := fn(req)
+		if err != ErrEncodeMatch {
+			t.Fatalf("%q: unexpected error: %v", accept, err)
+		}
+	}
+}
+
+func TestEncodeVary(t *testing.T) {
+	h := New()
+	var tests = []struct {
+		vary []string
+		want []string
+	}{
+		{nil, []string{"Accept"}},
+		{[]string{"Origin"}, []string{"Origin", "Accept"}},
+		{[]string{"Origin, accept"}, []string{"Origin, accept"}},
+		{[]string{"*"}, []string{"*"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		for _, v := range tt.vary {
+			w.Header().Add("Vary", v)
+		}
+		req := newTestRequest(http.MethodGet, "/", nil)
+		err := h.Encode(w, req, testData{N: 1}, http.StatusOK)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertDeepEqual(t, "vary", w.Header().Values("Vary"), tt.want)
+	}
+}
@@ -1,6 +1,9 @@
 package mux
 
 import (
+	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -27,10 +30,11 @@ func (fn ResolverFunc) Resolve(req *http.Request, code int, err error) Error {
 
 // ErrorView is the default error view.
 type ErrorView struct {
-	Code      int    `json:"code"`
-	Title     string `json:"title"`
-	Message   string `json:"message,omitempty"`
-	RequestID string `json:"request_id"`
+	XMLName   xml.Name `json:"-" xml:"error" csv:"-"`
+	Code      int      `json:"code" xml:"code" csv:"code"`
+	Title     string   `json:"title" xml:"title" csv:"title"`
+	Message   string   `json:"message,omitempty" xml:"message,omitempty" csv:"message"`
+	RequestID string   `json:"request_id" xml:"request_id" csv:"request_id"`
 }
 
 // Error implements the error interface.
@@ -70,6 +74,8 @@ func ErrorText(code int, err error) string {
 		return "Invalid content-type header."
 	case ErrDecodeRequestData:
 		return "Invalid request data."
+	case ErrTimeout:
+		return "The request timed out."
 	}
 	switch err.(type) {
 	case ErrMethodNotAllowed:
@@ -113,31 +119,61 @@ func (e Panic) String() string {
 
 // Abort resolves an error to a view and encodes the response.
 func (h *Handler) Abort(w http.ResponseWriter, req *http.Request, err error) {
+	if isTimedOut(w) {
+		// The response was resolved from ErrTimeout.
+		return
+	}
 	switch err {
 	case nil:
 		return
-	case ErrEncodeMatch:
+	}
+	setError(req, err)
+	if err == ErrEncodeMatch {
 		abort(w, http.StatusNotAcceptable)
 		return
 	}
-	defer h.observer.Abort(req)
+	defer requestObserver(h, req).Abort(req)
 	redirect, ok := err.(ErrRedirect)
 	if ok {
 		http.Redirect(w, req, redirect.URL, redirect.Code)
 		return
 	}
-	view := h.resolve(w, req, err)
-	code := view.StatusCode()
-	if code == http.StatusInternalServerError {
-		h.log(req, err)
-	}
-	err = h.Encode(w, req, view, code)
+	view := h.resolveError(w, req, err)
+	err = h.encode(h.errors, w, req, view, view.StatusCode())
 	if err != nil {
 		h.log(req, err)
 		abort(w, http.StatusInternalServerError)
 	}
 }
 
+// setError records the error of the request for the observer.
+func setError(req *http.Request, err error) {
+	rc, ok := req.Context().Value(requestContextKey).(*requestContext)
+	if ok {
+		rc.err = err
+	}
+}
+
+// requestObserver returns the observer of the request, which is the
+// observer of the root Handler if the handler is mounted.
+func requestObserver(h *Handler, req *http.Request) Observer {
+	rc, ok := req.Context().Value(requestContextKey).(*requestContext)
+	if ok && rc.root != nil {
+		return rc.root.observer
+	}
+	return h.observer
+}
+
+// resolveError resolves the error to an error view.
+// Internal server errors are logged.
+func (h *Handler) resolveError(w http.ResponseWriter, req *http.Request, err error) Error {
+	view := h.resolve(w, req, err)
+	if view.StatusCode() == http.StatusInternalServerError {
+		h.log(req, err)
+	}
+	return view
+}
+
 // resolve resolves errors to an error view.
 func (h *Handler) resolve(w http.ResponseWriter, req *http.Request, err error) Error {
 	switch err {
@@ -147,6 +183,11 @@ func (h *Handler) resolve(w http.ResponseWriter, req *http.Request, err error) E
 		return h.resolver.Resolve(req, http.StatusUnsupportedMediaType, err)
 	case ErrDecodeRequestData:
 		return h.resolver.Resolve(req, http.StatusBadRequest, err)
+	case ErrTimeout:
+		return h.resolver.Resolve(req, http.StatusServiceUnavailable, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return h.resolver.Resolve(req, http.StatusGatewayTimeout, err)
 	}
 	switch e := err.(type) {
 	case Error:
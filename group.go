@@ -0,0 +1,111 @@
+package mux
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// Group represents a set of routes registered with a shared pattern
+// prefix, route options, middleware and route name prefix.
+type Group struct {
+	h          *Handler
+	prefix     string
+	name       string
+	opts       []RouteOption
+	middleware []func(http.Handler) http.Handler
+}
+
+// Group returns a new route group.
+//
+// The prefix is prepended to the patterns of routes registered with the
+// group. The route options are applied before the options of routes
+// registered with the group. A route name set with WithName is joined
+// to the names of named routes registered with the group with a dot.
+func (h *Handler) Group(prefix string, opts ...RouteOption) *Group {
+	g := &Group{h: h}
+	return g.Group(prefix, opts...)
+}
+
+// Group returns a new route group nested within g.
+func (g *Group) Group(prefix string, opts ...RouteOption) *Group {
+	r := &Route{methods: make(map[string]struct{})}
+	for _, option := range opts {
+		option(r)
+	}
+	child := &Group{
+		h:      g.h,
+		prefix: g.pattern(prefix),
+		name:   joinName(g.name, r.name),
+		opts:   append(g.options(), opts...),
+	}
+	return child
+}
+
+// Add registers a HandlerFunc with the group.
+func (g *Group) Add(pattern string, handler HandlerFunc, opts ...RouteOption) *Route {
+	return g.h.Add(g.pattern(pattern), handler, g.routeOptions(opts)...)
+}
+
+// FileServer registers a fs.FS as a file server with the group.
+// See Handler.FileServer documentation for more details.
+func (g *Group) FileServer(pattern string, fs fs.FS, opts ...RouteOption) *Route {
+	return g.h.FileServer(g.pattern(pattern), fs, g.routeOptions(opts)...)
+}
+
+// Handle registers a standard net/http Handler with the group.
+func (g *Group) Handle(pattern string, handler http.Handler, opts ...RouteOption) *Route {
+	return g.h.Handle(g.pattern(pattern), handler, g.routeOptions(opts)...)
+}
+
+// Use appends middleware to the group middleware stack.
+func (g *Group) Use(middleware ...func(http.Handler) http.Handler) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// pattern returns the pattern prefixed with the group prefix.
+func (g *Group) pattern(pattern string) string {
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "/" + pattern
+	}
+	return strings.TrimSuffix(g.prefix, "/") + pattern
+}
+
+// options returns the group route options.
+func (g *Group) options() []RouteOption {
+	opts := make([]RouteOption, 0, len(g.opts)+1)
+	opts = append(opts, g.opts...)
+	if len(g.middleware) > 0 {
+		opts = append(opts, WithMiddleware(g.middleware...))
+	}
+	return opts
+}
+
+// routeOptions returns the options for a route registered with the group.
+func (g *Group) routeOptions(opts []RouteOption) []RouteOption {
+	rv := g.options()
+	rv = append(rv, WithName(""))
+	rv = append(rv, opts...)
+	rv = append(rv, withNamePrefix(g.name))
+	return rv
+}
+
+// withNamePrefix joins the prefix to the name of named routes.
+func withNamePrefix(prefix string) RouteOption {
+	return func(r *Route) {
+		if r.name != "" {
+			r.name = joinName(prefix, r.name)
+		}
+	}
+}
+
+// joinName joins route name segments with a dot.
+func joinName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return prefix + "." + name
+}
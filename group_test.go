@@ -0,0 +1,76 @@
+package mux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	have := ""
+	m := func(s string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				have += s
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	h := New()
+	h.Use(m("a"))
+	admin := h.Group("/admin", WithName("admin"), WithMiddleware(m("b")), WithMethod(http.MethodGet))
+	users := admin.Group("/users", WithName("users"), WithMiddleware(m("c")))
+	users.Add("/:id", testHandler, WithName("show"), WithMiddleware(m("d")))
+	users.Add("/", testHandler)
+	assertRouteBuild(t, h, "admin.users.show", Params{"id": "1"}, "/admin/users/1")
+	_, err := h.Build("admin.users", nil)
+	if err != ErrBuild {
+		t.Fatalf("unnamed group route should not be named after the group")
+	}
+	server := httptest.NewServer(h)
+	defer server.Close()
+	client := server.Client()
+	resp, err := client.Get(server.URL + "/admin/users/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertStatus(t, resp, http.StatusOK)
+	assertString(t, "body", string(b), "/admin/users/1")
+	assertString(t, "middleware", have, "abcd")
+	resp, err = client.Post(server.URL+"/admin/users/", "application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	assertStatus(t, resp, http.StatusMethodNotAllowed)
+}
+
+func TestGroupWalk(t *testing.T) {
+	h := New()
+	g := h.Group("/api", WithName("api"))
+	g.Add("/a", testHandler, WithName("a"))
+	g.Group("/v1", WithName("v1")).Add("/b", testHandler, WithName("b"))
+	have := make([]string, 0)
+	err := h.Walk(func(r *Route) error {
+		have = append(have, r.Name()+" "+r.Pattern())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDeepEqual(t, "walk", have, []string{"api.a /api/a", "api.v1.b /api/v1/b"})
+}
+
+func assertRouteBuild(t *testing.T, h *Handler, name string, params Params, want string) {
+	have, err := h.Build(name, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n for '%s'", err, name)
+	}
+	assertString(t, "build", have, want)
+}
@@ -2,6 +2,7 @@ package mux
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,8 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"runtime/debug"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,14 +23,23 @@ import (
 // Handler is a http.Handler with application lifecycle helpers.
 type Handler struct {
 	router     Router
+	mode       MatchMode
+	base       *url.URL
 	middleware []func(http.Handler) http.Handler
 	locales    *localeMatcher
 	decoder    DecoderFunc
 	encoder    EncoderFunc
+	errors     EncoderFunc
 	resolver   Resolver
 	pool       Pool
 	log        Logger
 	observer   Observer
+	mu         sync.RWMutex
+	mounts     map[string]*Route
+	redirect   bool
+	timeout    time.Duration
+	draining   int32
+	hooks      []func(ctx context.Context) error
 }
 
 // Logger represents the ability to log errors.
@@ -43,7 +55,7 @@ func New(opts ...Option) *Handler {
 		option(h)
 	}
 	if h.router == nil {
-		h.router = &tree{}
+		h.router = &tree{mode: h.mode}
 	}
 	if h.locales == nil {
 		h.locales = newLocaleMatcher([]language.Tag{language.English})
@@ -62,6 +74,9 @@ func New(opts ...Option) *Handler {
 			"application/json": encoder,
 		})
 	}
+	if h.errors == nil {
+		h.errors = h.encoder
+	}
 	if h.resolver == nil {
 		h.resolver = ResolverFunc(defaultResolver)
 	}
@@ -79,13 +94,7 @@ func New(opts ...Option) *Handler {
 
 // Add registers a HandlerFunc.
 func (h *Handler) Add(pattern string, handler HandlerFunc, opts ...RouteOption) *Route {
-	fn := func(w http.ResponseWriter, req *http.Request) {
-		err := handler(w, req)
-		if err != nil {
-			h.Abort(w, req, err)
-		}
-	}
-	return h.Handle(pattern, http.Handler(http.HandlerFunc(fn)), opts...)
+	return h.Handle(pattern, h.handler(handler), opts...)
 }
 
 // Build returns the URL for the named route.
@@ -94,7 +103,41 @@ func (h *Handler) Build(name string, params Params) (string, error) {
 	if !ok {
 		return "", errors.New("mux: router is not a Builder")
 	}
-	return b.Build(name, params)
+	url, err := b.Build(name, params)
+	if err == ErrBuild {
+		return h.buildMount(b, name, params)
+	}
+	return url, err
+}
+
+// BuildURL returns the URL for the named route with the query.
+//
+// The URL is resolved against the base URL if set with WithBaseURL.
+// The base URL path is prepended to the route path, unless the route
+// has a host pattern, in which case only the base URL scheme is used.
+func (h *Handler) BuildURL(name string, params Params, query url.Values) (*url.URL, error) {
+	s, err := h.Build(name, params)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if h.base != nil {
+		u.Scheme = h.base.Scheme
+		if u.Host == "" {
+			u.User = h.base.User
+			u.Host = h.base.Host
+			path := strings.TrimSuffix(h.base.EscapedPath(), "/") + u.EscapedPath()
+			u.RawPath = path
+			u.Path = unescape(path)
+		}
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u, nil
 }
 
 // FileServer registers a fs.FS as a file server.
@@ -117,9 +160,7 @@ func (h *Handler) FileServer(pattern string, fs fs.FS, opts ...RouteOption) *Rou
 
 // Handle registers a standard net/http Handler.
 func (h *Handler) Handle(pattern string, handler http.Handler, opts ...RouteOption) *Route {
-	opt := WithMiddleware(h.middleware...)
-	opts = append([]RouteOption{opt}, opts...)
-	r := NewRoute(pattern, handler, opts...)
+	r := h.route(pattern, handler, opts)
 	err := h.router.Add(r)
 	if err != nil {
 		panic(err)
@@ -127,6 +168,68 @@ func (h *Handler) Handle(pattern string, handler http.Handler, opts ...RouteOpti
 	return r
 }
 
+// Remove removes the named route if the Router is a Mutator.
+// It is safe to call Remove while the handler is serving requests.
+func (h *Handler) Remove(name string) error {
+	m, ok := h.router.(Mutator)
+	if !ok {
+		return errors.New("mux: router is not a Mutator")
+	}
+	err := m.Remove(name)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	delete(h.mounts, name)
+	h.mu.Unlock()
+	return nil
+}
+
+// Replace replaces the route of the same name with a new route for
+// the HandlerFunc if the Router is a Mutator. The route must be named
+// with WithName. It is safe to call Replace while the handler is
+// serving requests.
+func (h *Handler) Replace(pattern string, handler HandlerFunc, opts ...RouteOption) (*Route, error) {
+	return h.ReplaceHandler(pattern, h.handler(handler), opts...)
+}
+
+// ReplaceHandler replaces the route of the same name with a new route
+// for a standard net/http Handler as described by Replace.
+func (h *Handler) ReplaceHandler(pattern string, handler http.Handler, opts ...RouteOption) (*Route, error) {
+	m, ok := h.router.(Mutator)
+	if !ok {
+		return nil, errors.New("mux: router is not a Mutator")
+	}
+	r := h.route(pattern, handler, opts)
+	err := m.Replace(r)
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	delete(h.mounts, r.Name())
+	h.mu.Unlock()
+	return r, nil
+}
+
+// handler returns the HandlerFunc as a http.Handler that
+// aborts the request if an error is returned.
+func (h *Handler) handler(handler HandlerFunc) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		err := handler(w, req)
+		if err != nil {
+			h.Abort(w, req, err)
+		}
+	}
+	return http.HandlerFunc(fn)
+}
+
+// route returns a new route with the global middleware.
+func (h *Handler) route(pattern string, handler http.Handler, opts []RouteOption) *Route {
+	opt := WithMiddleware(h.middleware...)
+	opts = append([]RouteOption{opt}, opts...)
+	return NewRoute(pattern, handler, opts...)
+}
+
 // Redirect replies to the request with a redirect.
 func (h *Handler) Redirect(url string, code int) error {
 	return ErrRedirect{URL: url, Code: code}
@@ -134,25 +237,31 @@ func (h *Handler) Redirect(url string, code int) error {
 
 // RedirectTo replies to the request with a redirect to a named route.
 func (h *Handler) RedirectTo(name string, params Params, query url.Values, code int) error {
-	url, err := h.Build(name, params)
+	u, err := h.BuildURL(name, params, query)
 	if err != nil {
 		return err
 	}
-	if len(query) > 0 {
-		url += "?" + query.Encode()
-	}
-	return h.Redirect(url, code)
+	return h.Redirect(u.String(), code)
 }
 
 // ServeHTTP initializes a new request context and dispatches
 // to the matching route by calling it's prepared handler.
+// Requests of mounted handlers are observed by the parent.
 //
 // ServeHTTP implements the http.Handler interface.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	t := time.Now().UTC()
-	n := atomic.AddUint64(&seq, 1)
-	rc := &requestContext{seq: n, locale: h.locales.match(req)}
+	rc := h.newContext(req)
 	req = setContext(req, rc)
+	defer h.releaseContext(rc)
+	if rc.parent == nil {
+		var rw *responseWriter
+		w, rw = wrapResponseWriter(w)
+		h.observer.Begin(req)
+		defer h.commit(rw, req, t)
+	} else {
+		defer rc.propagate()
+	}
 	defer h.abort(w, req)
 	r, params, err := h.router.Match(req)
 	if err != nil {
@@ -162,16 +271,81 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			w.Header().Set("Allow", allowed)
 			return
 		}
-		h.Abort(w, req, err)
+		if err == ErrNotFound && h.redirect {
+			err = h.canonical(req)
+		}
+		h.Abort(w, req, mountRedirect(req, err))
 		return
 	}
 	rc.route = r
-	rc.params = params
-	h.observer.Begin(req)
-	defer h.observer.Commit(req, t)
+	if rc.merge(params) {
+		rc.pooled = params != nil
+	} else {
+		h.release(params)
+	}
+	d := h.routeTimeout(r)
+	if d > 0 {
+		if !h.serveTimeout(w, req, r, d) {
+			// The route may still read the params.
+			rc.pooled = false
+		}
+		return
+	}
 	r.ServeHTTP(w, req)
 }
 
+// release returns the params to the Router if it pools them.
+func (h *Handler) release(params Params) {
+	rel, ok := h.router.(releaser)
+	if ok && params != nil {
+		rel.release(params)
+	}
+}
+
+// releaseContext releases the params adopted by the request context
+// once the request is served. Params must not be retained by routes
+// after the request is served.
+func (h *Handler) releaseContext(rc *requestContext) {
+	if rc.pooled {
+		rc.pooled = false
+		h.release(rc.params)
+	}
+}
+
+// commit notifies the observer of the end of the request.
+func (h *Handler) commit(w *responseWriter, req *http.Request, t time.Time) {
+	o, ok := h.observer.(ResponseObserver)
+	if !ok {
+		h.observer.Commit(req, t)
+		return
+	}
+	rc := getContext(req)
+	o.CommitResponse(req, ResponseInfo{
+		Status: w.status(),
+		Bytes:  w.bytes,
+		Route:  rc.route,
+		Err:    rc.err,
+		Start:  t,
+	})
+}
+
+// newContext returns a new request context. The request context of
+// the parent Handler is inherited if the handler is mounted.
+func (h *Handler) newContext(req *http.Request) *requestContext {
+	parent, ok := req.Context().Value(requestContextKey).(*requestContext)
+	if ok {
+		params := make(Params)
+		for k, v := range parent.params {
+			if k != "*" {
+				params[k] = v
+			}
+		}
+		return &requestContext{h: h, root: parent.root, parent: parent, seq: parent.seq, params: params, locale: parent.locale, path: parent.path}
+	}
+	n := atomic.AddUint64(&seq, 1)
+	return &requestContext{h: h, root: h, seq: n, locale: h.locales.match(req), path: req.URL.EscapedPath()}
+}
+
 // abort resolves an error if the application panics.
 func (h *Handler) abort(w http.ResponseWriter, req *http.Request) {
 	err := recover()
@@ -186,13 +360,66 @@ func (h *Handler) Use(middleware ...func(http.Handler) http.Handler) {
 	h.middleware = append(h.middleware, middleware...)
 }
 
+// Validate returns the routing problems of the registered routes if
+// the Router is a Validator, such as conflicting param names, routes
+// shadowed by other routes, patterns with ambiguous splats and methods
+// registered on some but not all overlapping patterns. Routes of mounted
+// handlers are also validated. The nil slice is returned if the Router
+// is not a Validator.
+func (h *Handler) Validate() []Diagnostic {
+	v, ok := h.router.(Validator)
+	if !ok {
+		return nil
+	}
+	return v.Validate()
+}
+
 // Walk walks the named routes if the Router is a Walker.
+// The named routes of mounted handlers are walked in place of the
+// mount route. See Handler.Mount documentation for more details.
 func (h *Handler) Walk(fn WalkFunc) error {
 	w, ok := h.router.(Walker)
 	if !ok {
 		return errors.New("mux: router is not a Walker")
 	}
-	return w.Walk(fn)
+	return w.Walk(func(r *Route) error {
+		if r.mount != nil {
+			return walkMount(r, fn)
+		}
+		return fn(r)
+	})
+}
+
+// WalkAll walks every registered route, including unnamed routes,
+// sorting by pattern if the Router is an AllWalker. The routes of
+// mounted handlers are walked in place of the mount route.
+func (h *Handler) WalkAll(fn WalkFunc) error {
+	w, ok := h.router.(AllWalker)
+	if !ok {
+		return errors.New("mux: router is not an AllWalker")
+	}
+	routes := make([]*Route, 0)
+	err := w.WalkAll(func(r *Route) error {
+		if r.mount != nil {
+			return walkMountAll(r, func(r *Route) error {
+				routes = append(routes, r)
+				return nil
+			})
+		}
+		routes = append(routes, r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sortRoutes(routes)
+	for _, r := range routes {
+		err := fn(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Export walks the named routes and applies the exporter to the response body.
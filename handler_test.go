@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
@@ -103,7 +104,6 @@ func testPanic(t *testing.T, req *http.Request) {
 	h := New(WithLogger(testLogger))
 	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
 		panic(errors.New("test"))
-		return nil
 	}, WithMethod(http.MethodGet))
 	server := httptest.NewServer(h)
 	defer server.Close()
@@ -151,6 +151,112 @@ func TestFileServer(t *testing.T) {
 	assertString(t, "file", strings.TrimSpace(string(b)), "OK")
 }
 
+func TestRemove(t *testing.T) {
+	h := New()
+	h.Add("/a", testHandler, WithName("a"))
+	err := h.Remove("a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	h.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusNotFound)
+	err = h.Remove("a")
+	if err == nil {
+		t.Fatalf("should not remove missing named route")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	h := New()
+	h.Add("/a", testHandler, WithName("a"))
+	_, err := h.Replace("/a", func(w http.ResponseWriter, req *http.Request) error {
+		_, err := io.WriteString(w, "replaced")
+		return err
+	}, WithName("a"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	h.ServeHTTP(w, req)
+	assertString(t, "body", w.Body.String(), "replaced")
+}
+
+func TestReplaceHandler(t *testing.T) {
+	h := New()
+	h.Add("/a", testHandler, WithName("a"))
+	_, err := h.ReplaceHandler("/a", http.NotFoundHandler(), WithName("a"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	h.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusNotFound)
+}
+
+func TestBuildURL(t *testing.T) {
+	base, err := url.Parse("https://example.com/app/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var tests = []struct {
+		base  *url.URL
+		name  string
+		query url.Values
+		want  string
+	}{
+		{nil, "user", nil, "/users/a%20b"},
+		{nil, "user", url.Values{"q": []string{"x y"}}, "/users/a%20b?q=x+y"},
+		{base, "user", url.Values{"q": []string{"x"}}, "https://example.com/app/users/a%20b?q=x"},
+		{nil, "tenant", nil, "//acme.example.com/users/a%20b"},
+		{base, "tenant", nil, "https://acme.example.com/users/a%20b"},
+	}
+	for _, tt := range tests {
+		h := New(WithBaseURL(tt.base))
+		h.Add("/users/:id", testHandler, WithName("user"))
+		h.Add("/users/:id", testHandler, WithName("tenant"), WithHost("{tenant}.example.com"))
+		u, err := h.BuildURL(tt.name, Params{"id": "a b", "tenant": "acme"}, tt.query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertString(t, "url", u.String(), tt.want)
+	}
+}
+
+func TestURL(t *testing.T) {
+	base, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := New(WithBaseURL(base))
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		u, err := URL(req, "index", nil, url.Values{"a": []string{"b"}})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, u.String())
+		return err
+	}, WithName("index"))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, req)
+	assertString(t, "url", w.Body.String(), "https://example.com/?a=b")
+}
+
+func TestRedirectTo(t *testing.T) {
+	h := New()
+	h.Add("/users/:id", testHandler, WithName("user"))
+	err := h.RedirectTo("user", Params{"id": "1"}, url.Values{"a": []string{"b"}}, http.StatusFound)
+	redirect, ok := err.(ErrRedirect)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "url", redirect.URL, "/users/1?a=b")
+}
+
 func assertInt(t *testing.T, what string, have, want int) {
 	if have != want {
 		t.Fatalf("%s\nhave %d\nwant %d", what, have, want)
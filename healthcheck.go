@@ -22,11 +22,18 @@ func (fn HealthCheckerFunc) Check() error {
 //
 // The handler will respond with a plain text HTTP 200 OK if and only if
 // all checks return non-nil. If any check fails, the handler will respond
-// with a HTTP 500 Internal Server Error.
+// with a HTTP 500 Internal Server Error. While the serving Handler drains
+// requests during shutdown, the handler will respond with a HTTP 503
+// Service Unavailable.
 type HealthCheck []HealthChecker
 
 // ServeHTTP implements the http.Handler interface.
 func (h HealthCheck) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rc, ok := req.Context().Value(requestContextKey).(*requestContext)
+	if ok && rc.root != nil && rc.root.Draining() {
+		abort(w, http.StatusServiceUnavailable)
+		return
+	}
 	for _, checker := range h {
 		err := checker.Check()
 		if err != nil {
@@ -0,0 +1,215 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// hostLabels is the maximum number of labels in a hostname.
+const hostLabels = 127
+
+// host represents the routes of a host pattern.
+type host struct {
+	pattern string
+	labels  []string
+	keys    []string
+	methods map[string]*node
+}
+
+// newHost returns a new host for the pattern. Host patterns are
+// dot-separated labels where a label of the form {name} matches
+// any single label and is stored as a route parameter. The empty
+// pattern matches any host.
+func newHost(pattern string) (*host, error) {
+	h := &host{pattern: pattern, methods: make(map[string]*node)}
+	if pattern == "" {
+		return h, nil
+	}
+	h.labels = strings.Split(pattern, ".")
+	for i, label := range h.labels {
+		if isHostParam(label) {
+			h.keys = append(h.keys, label[1:len(label)-1])
+			continue
+		}
+		if strings.ContainsAny(label, "{}") {
+			return nil, fmt.Errorf("mux: invalid host pattern '%s'", pattern)
+		}
+		h.labels[i] = strings.ToLower(label)
+	}
+	return h, nil
+}
+
+// add adds r to the host by the pattern.
+func (h *host) add(r *Route, pattern string) error {
+	methods := r.Methods()
+	if len(methods) == 0 {
+		methods = append(methods, empty)
+	}
+	for _, method := range methods {
+		_, ok := h.methods[method]
+		if !ok {
+			h.methods[method] = &node{}
+		}
+		err := h.methods[method].addPattern(r, pattern)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rank returns the match priority of the host.
+func (h *host) rank() int {
+	if h.pattern == "" {
+		// The any host pattern is tried last.
+		return hostLabels + 1
+	}
+	n := 0
+	for _, label := range h.labels {
+		if isHostParam(label) {
+			n++
+		}
+	}
+	return n
+}
+
+// match reports whether the hostname matches the pattern,
+// appending the host param values to m.
+func (h *host) match(hostname string, m *match) bool {
+	if h.pattern == "" {
+		return true
+	}
+	k := len(m.values)
+	for i, label := range h.labels {
+		j := strings.IndexByte(hostname, '.')
+		if j < 0 {
+			j = len(hostname)
+		}
+		if i == len(h.labels)-1 && j != len(hostname) {
+			break
+		}
+		v := hostname[:j]
+		if isHostParam(label) {
+			if v == "" {
+				break
+			}
+			m.push(v, 0)
+		} else if label != v {
+			break
+		}
+		if i == len(h.labels)-1 {
+			m.keys = h.keys
+			return true
+		}
+		if j == len(hostname) {
+			break
+		}
+		hostname = hostname[j+1:]
+	}
+	m.truncate(k)
+	return false
+}
+
+// lookup sets the matching leaf for the method and path on m.
+func (h *host) lookup(method, path string, m *match) error {
+	root, ok := h.methods[method]
+	if ok && h.search(root, path, m) {
+		return nil
+	}
+	root, ok = h.methods[empty]
+	if ok && h.search(root, path, m) {
+		return nil
+	}
+	allowed := make([]string, 0)
+	for k, root := range h.methods {
+		if k == method || k == http.MethodOptions {
+			continue
+		}
+		if h.search(root, path, m) {
+			allowed = append(allowed, k)
+		}
+	}
+	m.leaf = nil
+	if len(allowed) > 0 {
+		allowed = append(allowed, http.MethodOptions)
+		sort.Strings(allowed)
+		return ErrMethodNotAllowed(allowed)
+	}
+	return ErrNotFound
+}
+
+// choose sets the leaf on m to the first route sharing the matched
+// pattern with predicates satisfied by the request. If every route of
+// the request method is rejected, the routes without methods matching
+// the path are tried before the predicate error is returned.
+func (h *host) choose(req *http.Request, path string, m *match) error {
+	l := m.leaf
+	err := m.choose(req)
+	if err == nil {
+		return nil
+	}
+	m.truncate(len(m.keys))
+	root, ok := h.methods[empty]
+	if !ok || !h.search(root, path, m) || m.leaf == l {
+		m.leaf = nil
+		return err
+	}
+	rv := m.choose(req)
+	if rv == nil || err == ErrNotFound {
+		return rv
+	}
+	return err
+}
+
+// search searches the root for the path, setting the matching leaf
+// and param values on m. The param values are reset on failure.
+func (h *host) search(root *node, path string, m *match) bool {
+	k := len(m.values)
+	n := root.search(path, m)
+	if n == nil {
+		m.truncate(k)
+		return false
+	}
+	m.leaf = n.leaf
+	return true
+}
+
+// build returns the hostname for the pattern and parameters.
+func (h *host) build(params Params) (string, error) {
+	labels := make([]string, len(h.labels))
+	for i, label := range h.labels {
+		if isHostParam(label) {
+			v, ok := params[label[1:len(label)-1]]
+			if !ok || v == "" {
+				return "", ErrBuild
+			}
+			label = v
+		}
+		labels[i] = label
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// hostname returns the lowercase request host without the port.
+func hostname(req *http.Request) string {
+	name := req.Host
+	if strings.HasPrefix(name, "[") {
+		i := strings.IndexByte(name, ']')
+		if i > 0 {
+			name = name[1:i]
+		}
+	} else {
+		i := strings.LastIndexByte(name, ':')
+		if i >= 0 {
+			name = name[:i]
+		}
+	}
+	return strings.ToLower(name)
+}
+
+func isHostParam(label string) bool {
+	n := len(label)
+	return n > 2 && label[0] == '{' && label[n-1] == '}' && !strings.ContainsAny(label[1:n-1], "{}")
+}
@@ -0,0 +1,119 @@
+package mux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mount dispatches requests to a mounted http.Handler.
+type mount struct {
+	handler http.Handler
+}
+
+// ServeHTTP rewrites the request URL path to the splat parameter
+// and dispatches the request to the mounted handler.
+//
+// ServeHTTP implements the http.Handler interface.
+func (m *mount) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	u := *req.URL
+	u.RawPath = "/" + RawParam(req, "*")
+	path, err := url.PathUnescape(u.RawPath)
+	if err != nil {
+		path = u.RawPath
+	}
+	u.Path = path
+	r := new(http.Request)
+	*r = *req
+	r.URL = &u
+	m.handler.ServeHTTP(w, r)
+}
+
+// Mount registers a http.Handler to serve requests with the prefix.
+// The prefix is removed from the request URL before handled.
+//
+// If handler is a *Handler, the request context of the parent is
+// preserved and the parent route parameters are merged with those of
+// the matched child route. Name the mount route with WithName to expose
+// the named child routes to Walk and Build under the route name.
+func (h *Handler) Mount(prefix string, handler http.Handler, opts ...RouteOption) *Route {
+	pattern := strings.TrimSuffix(prefix, "/") + "/*"
+	r := h.Handle(pattern, &mount{handler}, opts...)
+	child, ok := handler.(*Handler)
+	if ok {
+		r.mount = child
+		name := r.Name()
+		if name != "" {
+			h.mu.Lock()
+			if h.mounts == nil {
+				h.mounts = make(map[string]*Route)
+			}
+			h.mounts[name] = r
+			h.mu.Unlock()
+		}
+	}
+	return r
+}
+
+// Mount registers a http.Handler with the group.
+// See Handler.Mount documentation for more details.
+func (g *Group) Mount(prefix string, handler http.Handler, opts ...RouteOption) *Route {
+	return g.h.Mount(g.pattern(prefix), handler, g.routeOptions(opts)...)
+}
+
+// buildMount returns the URL for the named route of a mounted handler.
+func (h *Handler) buildMount(b Builder, name string, params Params) (string, error) {
+	for i := strings.LastIndexByte(name, '.'); i > 0; i = strings.LastIndexByte(name[:i], '.') {
+		h.mu.RLock()
+		m, ok := h.mounts[name[:i]]
+		h.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		path, err := m.mount.Build(name[i+1:], params)
+		if err != nil {
+			return "", err
+		}
+		p := make(Params)
+		for k, v := range params {
+			if k != "*" {
+				p[k] = v
+			}
+		}
+		prefix, err := b.Build(name[:i], p)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(prefix, "/") + path, nil
+	}
+	return "", ErrBuild
+}
+
+// walkMount yields the named routes of the mounted handler to fn.
+func walkMount(m *Route, fn WalkFunc) error {
+	return m.mount.Walk(func(r *Route) error {
+		c := *r
+		c.name = joinName(m.name, r.name)
+		if c.host == "" {
+			c.host = m.host
+		}
+		c.pattern = strings.TrimSuffix(m.pattern, "/*") + r.pattern
+		return fn(&c)
+	})
+}
+
+// walkMountAll yields every route of the mounted handler to fn.
+// Unnamed routes remain unnamed.
+func walkMountAll(m *Route, fn WalkFunc) error {
+	return m.mount.WalkAll(func(r *Route) error {
+		c := *r
+		if r.name != "" {
+			c.name = joinName(m.name, r.name)
+		}
+		if c.host == "" {
+			c.host = m.host
+		}
+		c.pattern = strings.TrimSuffix(m.pattern, "/*") + r.pattern
+		return fn(&c)
+	})
+}
@@ -0,0 +1,152 @@
+package mux
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMount(t *testing.T) {
+	var seq uint64
+	parent := New()
+	parent.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			seq = Sequence(req)
+			next.ServeHTTP(w, req)
+		})
+	})
+	child := New(WithLocales([]language.Tag{language.French}))
+	child.Add("/invoices/:id", func(w http.ResponseWriter, req *http.Request) error {
+		if Sequence(req) != seq {
+			return fmt.Errorf("sequence %d should be %d", Sequence(req), seq)
+		}
+		_, err := fmt.Fprintf(w, "%s %s %s %s", req.URL.Path, Param(req, "tenant"), Param(req, "id"), Locale(req))
+		return err
+	}, WithName("invoice"))
+	parent.Mount("/t/:tenant/billing", child, WithName("billing"))
+	server := httptest.NewServer(parent)
+	defer server.Close()
+	client := server.Client()
+	resp, err := client.Get(server.URL + "/t/acme/billing/invoices/42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertStatus(t, resp, http.StatusOK)
+	assertString(t, "body", string(b), "/invoices/42 acme 42 en")
+	resp, err = client.Get(server.URL + "/t/acme/billing/missing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	assertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestMountBuild(t *testing.T) {
+	parent := New()
+	child := New()
+	child.Add("/invoices/:id", testHandler, WithName("invoice"))
+	grandchild := New()
+	grandchild.Add("/", testHandler, WithName("index"))
+	child.Mount("/reports", grandchild, WithName("reports"))
+	parent.Mount("/t/:tenant/billing", child, WithName("billing"))
+	assertRouteBuild(t, parent, "billing.invoice", Params{"tenant": "acme", "id": "42"}, "/t/acme/billing/invoices/42")
+	assertRouteBuild(t, parent, "billing.reports.index", Params{"tenant": "acme"}, "/t/acme/billing/reports/")
+	_, err := parent.Build("billing.missing", nil)
+	if err != ErrBuild {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMountURL(t *testing.T) {
+	parent := New()
+	parent.Add("/", testHandler, WithName("index"))
+	child := New()
+	child.Add("/invoices/:id", func(w http.ResponseWriter, req *http.Request) error {
+		for _, name := range []string{"index", "billing.invoice"} {
+			params := Params{"tenant": Param(req, "tenant"), "id": "1"}
+			u, err := URL(req, name, params, nil)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w, u)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}, WithName("invoice"))
+	parent.Mount("/t/:tenant/billing", child, WithName("billing"))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/t/acme/billing/invoices/42", nil)
+	parent.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusOK)
+	assertString(t, "body", w.Body.String(), "/\n/t/acme/billing/invoices/1\n")
+}
+
+func TestMountWalk(t *testing.T) {
+	parent := New()
+	parent.Add("/", testHandler, WithName("index"))
+	child := New()
+	child.Add("/invoices", testHandler, WithName("invoices"))
+	child.Add("/", testHandler, WithName("index"))
+	parent.Mount("/billing", child, WithName("billing"))
+	have := make([]string, 0)
+	err := parent.Walk(func(r *Route) error {
+		have = append(have, r.Name()+" "+r.Pattern())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"billing.index /billing/", "billing.invoices /billing/invoices", "index /"}
+	assertDeepEqual(t, "walk", have, want)
+}
+
+func TestMountWalkHost(t *testing.T) {
+	parent := New()
+	child := New()
+	child.Add("/invoices", testHandler, WithName("invoices"))
+	child.Add("/admin", testHandler, WithName("admin"), WithHost("admin.example.com"))
+	parent.Mount("/billing", child, WithName("billing"), WithHost("billing.example.com"))
+	for _, walk := range []func(WalkFunc) error{parent.Walk, parent.WalkAll} {
+		have := make([]string, 0)
+		err := walk(func(r *Route) error {
+			have = append(have, r.Name()+" "+r.Host())
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{"billing.admin admin.example.com", "billing.invoices billing.example.com"}
+		assertDeepEqual(t, "walk", have, want)
+	}
+}
+
+func TestMountWalkAll(t *testing.T) {
+	parent := New()
+	parent.Add("/z", testHandler)
+	child := New()
+	child.Add("/invoices", testHandler, WithMethod(http.MethodGet), WithName("invoices"))
+	child.Add("/", testHandler)
+	parent.Mount("/billing", child, WithName("billing"))
+	have := make([]string, 0)
+	err := parent.WalkAll(func(r *Route) error {
+		have = append(have, r.Name()+" "+r.Pattern()+" "+strings.Join(r.Methods(), ","))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{" /billing/ ", "billing.invoices /billing/invoices GET,HEAD", " /z "}
+	assertDeepEqual(t, "walk", have, want)
+}
@@ -0,0 +1,88 @@
+package mux
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// NewNDJSONEncoder returns an Encoder of the application/x-ndjson media
+// type. Each element of slice and array views is encoded as a line of
+// JSON. Other views are encoded as a single line. The Encoder is a
+// StreamEncoder that encodes the terminal error record as a line of
+// JSON with the error view as the error member.
+func NewNDJSONEncoder() Encoder {
+	return &ndjsonEncoder{}
+}
+
+type ndjsonEncoder struct{}
+
+func (*ndjsonEncoder) Encode(w io.Writer, view Viewable) error {
+	enc := json.NewEncoder(w)
+	v := reflect.Indirect(reflect.ValueOf(view))
+	if !isList(v) {
+		return enc.Encode(view)
+	}
+	for i := 0; i < v.Len(); i++ {
+		err := enc.Encode(v.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*ndjsonEncoder) EncodeItem(w io.Writer, view Viewable) error {
+	return json.NewEncoder(w).Encode(view)
+}
+
+func (*ndjsonEncoder) EncodeError(w io.Writer, view Error) error {
+	return json.NewEncoder(w).Encode(map[string]Error{"error": view})
+}
+
+func (*ndjsonEncoder) Headers() http.Header {
+	return http.Header{"Content-Type": []string{"application/x-ndjson"}}
+}
+
+// NewNDJSONDecoder returns a Decoder of the application/x-ndjson media
+// type. Each line of JSON is appended to forms of slice type. Forms of
+// other types are decoded from a single line.
+func NewNDJSONDecoder() Decoder {
+	return &ndjsonDecoder{}
+}
+
+type ndjsonDecoder struct{}
+
+// Decode implements the Decoder interface.
+func (*ndjsonDecoder) Decode(req *http.Request, form Form) error {
+	defer req.Body.Close()
+	dec := json.NewDecoder(req.Body)
+	v := reflect.ValueOf(form)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return dec.Decode(form)
+	}
+	s := v.Elem()
+	for {
+		e := reflect.New(s.Type().Elem())
+		err := dec.Decode(e.Interface())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.Set(reflect.Append(s, e.Elem()))
+	}
+}
+
+// isList reports whether v is a slice, other than a byte slice, or an array.
+func isList(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	}
+	return false
+}
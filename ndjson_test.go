@@ -0,0 +1,84 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testNDJSONForm []testData
+
+func (f testNDJSONForm) Validate() error {
+	for _, v := range f {
+		err := v.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	var tests = []struct {
+		view Viewable
+		want string
+	}{
+		{[]testData{{N: 1}, {N: 2}}, "{\"n\":1}\n{\"n\":2}\n"},
+		{&[]testData{{N: 1}}, "{\"n\":1}\n"},
+		{[]testData{}, ""},
+		{testData{N: 1}, "{\"n\":1}\n"},
+		{[]byte("test"), "\"dGVzdA==\"\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := NewNDJSONEncoder().Encode(&b, tt.view)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertString(t, "body", b.String(), tt.want)
+	}
+}
+
+func TestNDJSONDecoder(t *testing.T) {
+	h := New(WithDecoder(NewContentTypeDecoder(map[string]Decoder{
+		"application/x-ndjson": NewNDJSONDecoder(),
+	})))
+	req := newTestRequest(http.MethodPost, "/", strings.NewReader("{\"n\":1}\n{\"n\":1}\n"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	var form testNDJSONForm
+	err := h.Decode(req, &form)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertInt(t, "len", len(form), 2)
+	req = newTestRequest(http.MethodPost, "/", strings.NewReader("{\"n\":1}\n{"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	err = h.Decode(req, &form)
+	if err != ErrDecodeRequestData {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req = newTestRequest(http.MethodPost, "/", strings.NewReader("{\"n\":1}\n"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	var data testData
+	err = h.Decode(req, &data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertInt(t, "n", data.N, 1)
+}
+
+func TestNDJSONErrorView(t *testing.T) {
+	h := New(WithEncoder(NewAcceptEncoder(map[string]Encoder{
+		"application/x-ndjson": NewNDJSONEncoder(),
+	})))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	req.Header.Set("X-Request-ID", "test")
+	h.ServeHTTP(w, req)
+	resp := w.Result()
+	assertStatus(t, resp, http.StatusNotFound)
+	assertHeader(t, resp, "Content-Type", "application/x-ndjson")
+	assertString(t, "body", w.Body.String(), `{"code":404,"title":"Not Found","request_id":"test"}`+"\n")
+}
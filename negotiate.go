@@ -0,0 +1,164 @@
+package mux
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mediaRange represents a media range of an Accept header.
+type mediaRange struct {
+	mediaType string
+	params    map[string]string
+	q         float64
+}
+
+// parseAccept returns the media ranges of the Accept header value.
+// Malformed entries and entries with an invalid quality are ignored.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, v := range strings.Split(accept, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(v)
+		if err != nil || strings.Count(mediaType, "/") != 1 {
+			continue
+		}
+		q := 1.0
+		s, ok := params["q"]
+		if ok {
+			q, err = strconv.ParseFloat(s, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			delete(params, "q")
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, params: params, q: q})
+	}
+	return ranges
+}
+
+// specificity returns the specificity of the media range for the media
+// type and reports whether the media range matches the media type.
+// Media types with parameters are matched by media ranges with a subset
+// of the parameters. Exact media ranges are more specific than wildcard
+// subtypes, which are more specific than */*, and media ranges with more
+// parameters are more specific than media ranges with fewer parameters.
+func (r mediaRange) specificity(mediaType string, params map[string]string) (int, bool) {
+	if !matchMediaType(r.mediaType, mediaType) {
+		return 0, false
+	}
+	for k, v := range r.params {
+		if !strings.EqualFold(params[k], v) {
+			return 0, false
+		}
+	}
+	n := 2
+	switch {
+	case r.mediaType == "*/*":
+		n = 0
+	case strings.HasSuffix(r.mediaType, "/*"):
+		n = 1
+	}
+	return n<<8 | len(r.params), true
+}
+
+// quality returns the quality of the most specific media range matching
+// the media type and reports whether any media range matched.
+func quality(ranges []mediaRange, mediaType string, params map[string]string) (float64, bool) {
+	specificity := -1
+	q := 0.0
+	for _, r := range ranges {
+		n, ok := r.specificity(mediaType, params)
+		if !ok || n <= specificity {
+			continue
+		}
+		specificity = n
+		q = r.q
+	}
+	return q, specificity >= 0
+}
+
+// accepts reports whether the Accept header value accepts the media type.
+// The quality of the most specific matching media range applies, so a
+// media type excluded with a quality of zero is not accepted by a wildcard.
+// Malformed entries are ignored.
+func accepts(accept, mediaType string) bool {
+	var params map[string]string
+	v, p, err := mime.ParseMediaType(mediaType)
+	if err == nil {
+		mediaType = v
+		params = p
+	}
+	q, ok := quality(parseAccept(accept), mediaType, params)
+	return ok && q > 0
+}
+
+// offer represents a media type offered by the server.
+type offer struct {
+	key       string
+	mediaType string
+	params    map[string]string
+}
+
+// newOffers returns the offers of the media types in order of server
+// preference. The preferred media types are ordered first, followed by
+// the remaining media types without wildcards and then the remaining
+// media types with wildcards, each in lexical order. The empty media
+// type is not offered.
+func newOffers(mediaTypes []string, preference []string) []offer {
+	rank := make(map[string]int, len(preference))
+	for i, v := range preference {
+		_, ok := rank[v]
+		if !ok {
+			rank[v] = i
+		}
+	}
+	less := func(a, b string) bool {
+		i, aok := rank[a]
+		j, bok := rank[b]
+		if aok || bok {
+			return aok && (!bok || i < j)
+		}
+		aw := strings.Contains(a, "*")
+		bw := strings.Contains(b, "*")
+		if aw != bw {
+			return bw
+		}
+		return a < b
+	}
+	sort.Slice(mediaTypes, func(i, j int) bool {
+		return less(mediaTypes[i], mediaTypes[j])
+	})
+	offers := make([]offer, 0, len(mediaTypes))
+	for _, key := range mediaTypes {
+		if key == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(key)
+		if err != nil {
+			mediaType = strings.ToLower(key)
+		}
+		offers = append(offers, offer{key: key, mediaType: mediaType, params: params})
+	}
+	return offers
+}
+
+// negotiate returns the key of the offer with the highest quality
+// and reports whether any offer is acceptable. Offers of the same
+// quality are chosen in order of server preference.
+func negotiate(ranges []mediaRange, offers []offer) (string, bool) {
+	key := ""
+	best := 0.0
+	for _, o := range offers {
+		q, ok := quality(ranges, o.mediaType, o.params)
+		if ok && q > best {
+			key = o.key
+			best = q
+		}
+	}
+	return key, best > 0
+}
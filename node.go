@@ -1,73 +1,128 @@
 package mux
 
 import (
-	"errors"
 	"fmt"
+	"net/http"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
 )
 
 type node struct {
-	param bool
-	label string
-	route *Route
-	edges []*node
+	param  bool
+	label  string
+	expr   string
+	re     *regexp.Regexp
+	breaks string
+	leaf   *leaf
+	edges  []*node
+}
+
+// leaf represents a route at a terminal node. Routes with predicates
+// sharing the pattern are chained in order of registration.
+type leaf struct {
+	route   *Route
+	pattern string
+	keys    []string
+	next    *leaf
+}
+
+// add appends l to the routes sharing the pattern. The route of l is
+// unreachable if a route without predicates has already been added.
+func (p *leaf) add(l *leaf) error {
+	for {
+		if len(p.route.predicates) == 0 {
+			return fmt.Errorf("mux: duplicate route pattern '%s'", l.route.pattern)
+		}
+		if p.next == nil {
+			p.next = l
+			return nil
+		}
+		p = p.next
+	}
 }
 
 func (n *node) add(r *Route) error {
-	pattern := r.Pattern()
-	return n.addNode(r, pattern)
+	return n.addPattern(r, r.Pattern())
+}
+
+// addPattern adds r to the node by the pattern in place of the route pattern.
+func (n *node) addPattern(r *Route, pattern string) error {
+	patterns, err := expand(pattern)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		l := &leaf{route: r, pattern: pattern, keys: paramKeys(pattern)}
+		err := n.addNode(l, pattern)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (n *node) addNode(r *Route, pattern string) error {
+func (n *node) addNode(l *leaf, pattern string) error {
 	if pattern == "" {
-		if n.route != nil {
-			return fmt.Errorf("mux: duplicate route pattern '%s'", r.pattern)
+		if n.leaf != nil {
+			return n.leaf.add(l)
 		}
-		n.route = r
+		n.leaf = l
 		return nil
 	}
 	switch pattern[0] {
 	case ':':
-		return n.addParam(r, pattern)
+		return n.addParam(l, pattern)
 	case '*':
-		return n.addSplat(r, pattern)
+		return n.addSplat(l, pattern)
 	}
-	return n.addStatic(r, pattern)
+	return n.addStatic(l, pattern)
 }
 
 func (n *node) addEdge(e *node) *node {
 	n.edges = append(n.edges, e)
-	sort.Slice(n.edges, func(i, j int) bool {
-		if n.edges[i].param {
-			if n.edges[j].param {
-				return n.edges[j].label == "*"
-			}
-			return false
+	sort.SliceStable(n.edges, func(i, j int) bool {
+		a, b := n.edges[i], n.edges[j]
+		if a.param != b.param {
+			return b.param
 		}
-		if n.edges[j].param {
-			return true
+		if a.param {
+			return a.rank() < b.rank()
 		}
-		return n.edges[i].label < n.edges[j].label
+		return a.label < b.label
 	})
+	n.breaks = ""
+	for _, e := range n.edges {
+		c := e.label[0]
+		if strings.IndexByte(n.breaks, c) < 0 {
+			n.breaks += string(c)
+		}
+	}
 	return e
 }
 
-func (n *node) addStatic(r *Route, pattern string) error {
-	i := prefixIndex(n.label, pattern)
-	j := 0
-	for j < len(pattern) {
-		if isParam(pattern[j]) && isBreak(pattern[j-1]) {
-			break
-		}
-		j++
+// rank returns the match priority of a param node. Constrained params
+// are tried before unconstrained params, which are tried before splats.
+func (n *node) rank() int {
+	switch {
+	case n.label == "*":
+		return 2
+	case n.re == nil:
+		return 1
 	}
+	return 0
+}
+
+func (n *node) addStatic(l *leaf, pattern string) error {
+	i := prefixIndex(n.label, pattern)
+	j := nextParam(pattern)
 	if n.label == "" {
 		n.label = pattern[:j]
-		return n.addNode(r, pattern[j:])
+		return n.addNode(l, pattern[j:])
 	}
-	if n.label == "/" && pattern == "/" {
-		return n.addNode(r, "")
+	if n.label == pattern {
+		return n.addNode(l, "")
 	}
 	if i == 0 || i == len(n.label) {
 		prefix := pattern[i]
@@ -76,140 +131,259 @@ func (n *node) addStatic(r *Route, pattern string) error {
 				break
 			}
 			if prefixIndex(e.label, pattern[i:]) > 0 {
-				return e.addNode(r, pattern[i:])
+				return e.addNode(l, pattern[i:])
 			}
 		}
 	} else {
-		s := &node{label: n.label[i:], route: n.route, edges: n.edges}
+		s := &node{label: n.label[i:], leaf: n.leaf, edges: n.edges}
 		n.label = pattern[:i]
-		n.route = nil
+		n.leaf = nil
 		n.edges = []*node{s}
 	}
 	if j > i {
 		n = n.addEdge(&node{label: pattern[i:j]})
 	}
-	return n.addNode(r, pattern[j:])
+	return n.addNode(l, pattern[j:])
 }
 
-func (n *node) addParam(r *Route, pattern string) error {
-	i := len(pattern)
-	for j := 1; j < len(pattern); j++ {
-		if isParam(pattern[j]) {
-			return fmt.Errorf("mux: invalid param '%s'", pattern[:j])
-		}
-		if isBreak(pattern[j]) {
-			i = j
-			break
+func (n *node) addParam(l *leaf, pattern string) error {
+	name, expr, i := parseParam(pattern)
+	if i < 0 {
+		return fmt.Errorf("mux: unterminated param constraint '%s'", pattern)
+	}
+	if name == "" {
+		return fmt.Errorf("mux: invalid param '%s'", pattern[:i])
+	}
+	if i < len(pattern) && !isBreak(pattern[i]) {
+		return fmt.Errorf("mux: invalid param '%s'", pattern[:i+1])
+	}
+	for j := 0; j < len(name); j++ {
+		if isParam(name[j]) {
+			return fmt.Errorf("mux: invalid param '%s'", pattern[:j+1])
 		}
 	}
 	for _, e := range n.edges {
-		if e.param {
-			return e.addNode(r, pattern[i:])
+		if e.param && e.label != "*" && e.expr == expr {
+			return e.addNode(l, pattern[i:])
 		}
 	}
-	n = n.addEdge(&node{param: true, label: pattern[1:i]})
-	return n.addNode(r, pattern[i:])
+	e := &node{param: true, label: name}
+	if expr != "" {
+		re, err := compileConstraint(expr)
+		if err != nil {
+			return err
+		}
+		e.expr = expr
+		e.re = re
+	}
+	n = n.addEdge(e)
+	return n.addNode(l, pattern[i:])
 }
 
-func (n *node) addSplat(r *Route, pattern string) error {
-	if pattern != "*" {
-		return errors.New("mux: splat only supported at terminus")
+func (n *node) addSplat(l *leaf, pattern string) error {
+	_, i := parseSplat(pattern)
+	for j := 1; j < i; j++ {
+		if isParam(pattern[j]) {
+			return fmt.Errorf("mux: invalid splat '%s'", pattern[:j+1])
+		}
+	}
+	for _, e := range n.edges {
+		if e.param && e.label == "*" {
+			return e.addNode(l, pattern[i:])
+		}
 	}
-	n = n.addEdge(&node{param: true, label: pattern[:1]})
-	return n.addNode(r, pattern[1:])
+	n = n.addEdge(&node{param: true, label: "*"})
+	return n.addNode(l, pattern[i:])
 }
 
 func (n *node) match(path string) (*Route, Params, error) {
-	var params Params
-	m := n.search(path)
-	if m == nil {
+	m := &match{}
+	p := n.search(path, m)
+	if p == nil {
 		return nil, nil, ErrNotFound
 	}
-	pattern := m.route.pattern
-	for {
-		i := prefixIndex(pattern, path)
-		path = path[i:]
-		pattern = pattern[i:]
-		if params == nil {
-			params = make(Params)
-		}
-		if pattern == "*" {
-			params[pattern] = path
-			break
-		}
-		var bc byte
-		var j, k int
-		if path == "" {
-			break
-		}
-		for ; j < len(pattern); j++ {
-			if isBreak(pattern[j]) {
-				bc = pattern[j]
-				break
-			}
-		}
-		for ; k < len(path); k++ {
-			if path[k] == bc {
-				break
-			}
-		}
-		key := pattern[1:j]
-		params[key] = path[:k]
-		path = path[k:]
-		pattern = pattern[j:]
+	m.leaf = p.leaf
+	params := m.params()
+	if params == nil {
+		params = make(Params)
 	}
-	return m.route, params, nil
+	return m.leaf.route, params, nil
 }
 
-func (n *node) search(path string) *node {
-	if n.param && n.label == "*" {
-		return n
-	}
-	if n.param {
-		i := 0
-		bc := make(map[byte]struct{})
-		for _, e := range n.edges {
-			bc[e.label[0]] = struct{}{}
+// search returns the terminal node matching the path, appending the
+// param values to m. Splats are greedy and yield to the longest
+// match of the pattern that follows them.
+func (n *node) search(path string, m *match) *node {
+	k := len(m.values)
+	switch {
+	case n.param && n.label == "*":
+		return n.searchSplat(path, m)
+	case n.param:
+		breaks := n.breaks
+		if breaks == "" {
+			breaks = "/"
 		}
-		if len(bc) == 0 {
-			bc['/'] = struct{}{}
-		}
-	loop:
-		for ; i < len(path); i++ {
-			for c := range bc {
-				if path[i] == c {
-					break loop
-				}
-			}
+		i := 0
+		for i < len(path) && strings.IndexByte(breaks, path[i]) < 0 {
+			i++
 		}
 		if i == 0 {
 			return nil
 		}
+		if n.re != nil && !n.re.MatchString(path[:i]) {
+			return nil
+		}
+		m.push(path[:i], len(path))
 		path = path[i:]
-	} else {
-		if !strings.HasPrefix(path, n.label) {
+	default:
+		if !hasPrefix(path, n.label, m.fold) {
 			return nil
 		}
 		path = path[len(n.label):]
-		if path == "" && n.route != nil {
+		if path == "" && n.leaf != nil {
 			return n
 		}
 	}
 	for _, e := range n.edges {
-		p := e.search(path)
+		p := e.search(path, m)
 		if p != nil {
 			return p
 		}
 	}
-	if path != "" || n.route == nil {
+	if path != "" || n.leaf == nil {
+		m.truncate(k)
+		return nil
+	}
+	return n
+}
+
+// searchSplat returns the terminal node matching the path for a splat.
+// The edges are tried against the longest remainder of the path first
+// before the splat itself is matched as the terminal node.
+func (n *node) searchSplat(path string, m *match) *node {
+	k := len(m.values)
+	if len(n.edges) > 0 {
+		for i := len(path); i >= 0; i-- {
+			m.push(path[:i], len(path))
+			for _, e := range n.edges {
+				p := e.search(path[i:], m)
+				if p != nil {
+					return p
+				}
+			}
+			m.truncate(k)
+		}
+	}
+	if n.leaf == nil {
 		return nil
 	}
+	m.push(path, len(path))
 	return n
 }
 
+// match represents a matched leaf and the host and path param values
+// captured while searching. Matches are pooled to avoid allocations.
+// The length of the path remaining at each value is recorded in rest
+// to locate the path param values within the matched path.
+type match struct {
+	fold   bool
+	keys   []string
+	leaf   *leaf
+	values []string
+	rest   []int
+}
+
+// matches is the pool of matches.
+var matches = sync.Pool{
+	New: func() interface{} {
+		return &match{values: make([]string, 0, 8), rest: make([]int, 0, 8)}
+	},
+}
+
+// push appends the value with the length of the path remaining.
+func (m *match) push(v string, rest int) {
+	m.values = append(m.values, v)
+	m.rest = append(m.rest, rest)
+}
+
+// truncate discards the values after the first k.
+func (m *match) truncate(k int) {
+	m.values = m.values[:k]
+	m.rest = m.rest[:k]
+}
+
+// getMatch returns a reset match from the pool.
+func getMatch() *match {
+	m := matches.Get().(*match)
+	m.fold = false
+	m.keys = nil
+	m.leaf = nil
+	m.truncate(0)
+	return m
+}
+
+// putMatch returns the match to the pool.
+func putMatch(m *match) {
+	matches.Put(m)
+}
+
+// choose sets the leaf to the first route sharing the pattern with
+// predicates satisfied by the request. If no route matches, the first
+// predicate error other than ErrNotFound is returned.
+func (m *match) choose(req *http.Request) error {
+	var rv error = ErrNotFound
+	for l := m.leaf; l != nil; l = l.next {
+		err := l.route.test(req)
+		if err == nil {
+			m.leaf = l
+			return nil
+		}
+		if rv == ErrNotFound {
+			rv = err
+		}
+	}
+	m.leaf = nil
+	return rv
+}
+
+// paramsPool is the pool of params returned by match.params.
+var paramsPool = sync.Pool{
+	New: func() interface{} {
+		return make(Params, 8)
+	},
+}
+
+// putParams clears the params and returns them to the pool.
+func putParams(params Params) {
+	if params == nil {
+		return
+	}
+	for k := range params {
+		delete(params, k)
+	}
+	paramsPool.Put(params)
+}
+
+// params returns the param values as pooled Params, or nil if there
+// are none. Release the params with putParams once no longer in use.
+func (m *match) params() Params {
+	if len(m.values) == 0 {
+		return nil
+	}
+	params := paramsPool.Get().(Params)
+	for i, key := range m.keys {
+		params[key] = m.values[i]
+	}
+	values := m.values[len(m.keys):]
+	for i, key := range m.leaf.keys {
+		params[key] = values[i]
+	}
+	return params
+}
+
 func (n *node) walk(fn WalkFunc) error {
-	if n.route != nil {
-		err := fn(n.route)
+	for l := n.leaf; l != nil; l = l.next {
+		err := fn(l.route)
 		if err != nil {
 			return err
 		}
@@ -246,6 +420,15 @@ func min(a, b int) int {
 	return b
 }
 
+// hasPrefix reports whether s begins with prefix,
+// ignoring case if fold is true.
+func hasPrefix(s, prefix string, fold bool) bool {
+	if !fold {
+		return strings.HasPrefix(s, prefix)
+	}
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
 func prefixIndex(a, b string) int {
 	n := min(len(a), len(b))
 	for i := 0; i < n; i++ {
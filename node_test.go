@@ -96,6 +96,24 @@ func TestNodeAddStaticNested(t *testing.T) {
 	assertEdges(t, n.edges[0].edges[0].edges[0], nil)
 }
 
+func TestNodeAddStaticExisting(t *testing.T) {
+	var tests = []string{
+		"/a/:b",
+		"/a/",
+	}
+	n := &node{}
+	for _, pattern := range tests {
+		r := NewRoute(pattern, nil)
+		err := n.add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assertRoute(t, n, "/a/", "/a/")
+	assertEdges(t, n, []string{":b"})
+	assertRoute(t, n.edges[0], ":b", "/a/:b")
+}
+
 func TestNodeAddParam(t *testing.T) {
 	var tests = []string{
 		"/z",     // /z
@@ -143,6 +161,95 @@ func TestNodeAddDuplicate(t *testing.T) {
 	}
 }
 
+func TestNodeAddParamConstraint(t *testing.T) {
+	var tests = []string{
+		"/:a",
+		"/:a{int}",
+		"/:a{alpha}",
+		"/*",
+	}
+	n := &node{}
+	for _, pattern := range tests {
+		r := NewRoute(pattern, nil)
+		err := n.add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assertEdges(t, n, []string{":a", ":a", ":a", "*"})
+	assertString(t, "constraint", n.edges[0].expr, "int")
+	assertString(t, "constraint", n.edges[1].expr, "alpha")
+	assertString(t, "constraint", n.edges[2].expr, "")
+}
+
+func TestNodeAddParamConstraintInvalid(t *testing.T) {
+	var tests = []string{
+		"/:a{",
+		"/:a{[a-z}",
+		"/:a{[}",
+		"/:{int}",
+		"/:{x}",
+		"/:",
+		"/a/:",
+		"/x.:",
+		"/:a{int}{x}",
+		"/:a{int}x",
+	}
+	for _, pattern := range tests {
+		n := &node{}
+		r := NewRoute(pattern, nil)
+		err := n.add(r)
+		if err == nil {
+			t.Fatalf("should not add invalid constraint '%s'", pattern)
+		}
+	}
+}
+
+func TestNodeMatchConstraint(t *testing.T) {
+	var patterns = []string{
+		"/users/:id{int}",
+		"/users/:id{uuid}/posts",
+		"/users/:slug{[a-z-]+}",
+		"/files/:name{[0-9]{3}}.json",
+	}
+	var tests = []struct {
+		path    string
+		pattern string
+		params  Params
+	}{
+		{"/users/42", "/users/:id{int}", Params{"id": "42"}},
+		{"/users/-1", "/users/:id{int}", Params{"id": "-1"}},
+		{"/users/abc-def", "/users/:slug{[a-z-]+}", Params{"slug": "abc-def"}},
+		{"/users/0d5e3c9a-1b2c-4d5e-8f90-a1b2c3d4e5f6/posts", "/users/:id{uuid}/posts", Params{"id": "0d5e3c9a-1b2c-4d5e-8f90-a1b2c3d4e5f6"}},
+		{"/users/ABC", "", nil},
+		{"/users/42/posts", "", nil},
+		{"/files/123.json", "/files/:name{[0-9]{3}}.json", Params{"name": "123"}},
+		{"/files/1234.json", "", nil},
+	}
+	n := &node{}
+	for _, pattern := range patterns {
+		r := NewRoute(pattern, nil)
+		err := n.add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, tt := range tests {
+		r, params, err := n.match(tt.path)
+		if tt.pattern == "" {
+			if err != ErrNotFound {
+				t.Fatalf("match\npath '%s'\nhave %#v\nwant %#v", tt.path, err, ErrNotFound)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n for '%s'", err, tt.path)
+		}
+		assertString(t, "route", r.Pattern(), tt.pattern)
+		assertDeepEqual(t, "params", params, tt.params)
+	}
+}
+
 // Patterns borrowed from Goji as a reference.
 // https://github.com/goji/goji/blob/0d89ff54b2c18c9c4ba530e32496aef902d3c6cd/pat/pat_test.go
 func TestNodeMatch(t *testing.T) {
@@ -218,6 +325,95 @@ func TestNodeMatch(t *testing.T) {
 	}
 }
 
+func TestNodeMatchSplat(t *testing.T) {
+	var patterns = []string{
+		"/files/*",
+		"/files/*/raw",
+		"/repo/*path/blob/:file",
+		"/repo/*path/tree",
+	}
+	var tests = []struct {
+		path    string
+		pattern string
+		params  Params
+	}{
+		{"/files/a/b", "/files/*", Params{"*": "a/b"}},
+		{"/files/a/b/raw", "/files/*/raw", Params{"*": "a/b"}},
+		{"/files/raw/raw", "/files/*/raw", Params{"*": "raw"}},
+		{"/files/a/raw/b", "/files/*", Params{"*": "a/raw/b"}},
+		{"/repo/a/b/blob/main.go", "/repo/*path/blob/:file", Params{"path": "a/b", "file": "main.go"}},
+		{"/repo/a/blob/b/blob/c", "/repo/*path/blob/:file", Params{"path": "a/blob/b", "file": "c"}},
+		{"/repo/a/b/tree", "/repo/*path/tree", Params{"path": "a/b"}},
+		{"/repo/a/b", "", nil},
+	}
+	n := &node{}
+	for _, pattern := range patterns {
+		r := NewRoute(pattern, nil)
+		err := n.add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, tt := range tests {
+		r, params, err := n.match(tt.path)
+		if tt.pattern == "" {
+			if err != ErrNotFound {
+				t.Fatalf("match\npath '%s'\nhave %#v\nwant %#v", tt.path, err, ErrNotFound)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n for '%s'", err, tt.path)
+		}
+		assertString(t, "route", r.Pattern(), tt.pattern)
+		assertDeepEqual(t, "params", params, tt.params)
+	}
+}
+
+func TestNodeMatchOptional(t *testing.T) {
+	var tests = []struct {
+		path   string
+		match  bool
+		params Params
+	}{
+		{"/docs", true, Params{}},
+		{"/docs/v1", true, Params{"version": "v1"}},
+		{"/docs/v1/intro", true, Params{"version": "v1", "page": "intro"}},
+		{"/docs/", false, nil},
+	}
+	n := &node{}
+	r := NewRoute("/docs(/:version(/:page))", nil)
+	err := n.add(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		_, params, err := n.match(tt.path)
+		if !tt.match {
+			if err != ErrNotFound {
+				t.Fatalf("match\npath '%s'\nhave %#v\nwant %#v", tt.path, err, ErrNotFound)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n for '%s'", err, tt.path)
+		}
+		assertDeepEqual(t, "params", params, tt.params)
+	}
+}
+
+func TestNodeAddOptionalDuplicate(t *testing.T) {
+	n := &node{}
+	err := n.add(NewRoute("/docs", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = n.add(NewRoute("/docs(/:version)", nil))
+	if err == nil {
+		t.Fatalf("should not add duplicate optional pattern route")
+	}
+}
+
 func assertLabel(t *testing.T, n *node, label string) {
 	have := n.label
 	if n.param && n.label != "*" {
@@ -227,18 +423,18 @@ func assertLabel(t *testing.T, n *node, label string) {
 }
 
 func assertNode(t *testing.T, n *node, label string) {
-	if n.route != nil {
-		t.Fatalf("should be nil route\nhave '%s' => '%s'", label, n.route.pattern)
+	if n.leaf != nil {
+		t.Fatalf("should be nil route\nhave '%s' => '%s'", label, n.leaf.route.pattern)
 	}
 	assertLabel(t, n, label)
 }
 
 func assertRoute(t *testing.T, n *node, label, pattern string) {
-	if n.route == nil {
+	if n.leaf == nil {
 		t.Fatalf("should be non-nil route for '%s'", pattern)
 	}
 	assertLabel(t, n, label)
-	assertString(t, "route", n.route.pattern, pattern)
+	assertString(t, "route", n.leaf.route.pattern, pattern)
 }
 
 func assertEdges(t *testing.T, n *node, edges []string) {
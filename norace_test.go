@@ -0,0 +1,7 @@
+//go:build !race
+// +build !race
+
+package mux
+
+// raceEnabled reports whether the race detector is enabled.
+const raceEnabled = false
@@ -11,7 +11,7 @@ type Observer interface {
 	Abort(req *http.Request)
 
 	// Begin is called immediately after the request context is
-	// initialized and before the route is dispatched.
+	// initialized and before the route is matched.
 	Begin(req *http.Request)
 
 	// Commit is called at the end of the request. The start time of
@@ -19,6 +19,35 @@ type Observer interface {
 	Commit(req *http.Request, t time.Time)
 }
 
+// ResponseObserver represents the ability to observe a request
+// and its response.
+type ResponseObserver interface {
+	Observer
+
+	// CommitResponse is called at the end of the request in place of
+	// Commit with the details of the response.
+	CommitResponse(req *http.Request, resp ResponseInfo)
+}
+
+// ResponseInfo represents the details of a response.
+type ResponseInfo struct {
+	// Status is the status code of the response.
+	Status int
+
+	// Bytes is the number of response body bytes written.
+	Bytes int64
+
+	// Route is the matched route or nil if no route matched. The route
+	// of a mounted Handler is reported in place of the mount route.
+	Route *Route
+
+	// Err is the last error resolved to a view or nil if none.
+	Err error
+
+	// Start is the start time of the request.
+	Start time.Time
+}
+
 type discardObserver struct{}
 
 func (r *discardObserver) Abort(req *http.Request)               {}
@@ -1,3 +1,164 @@
 package mux
 
-var _ Observer = &discardObserver{}
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	_ Observer         = &discardObserver{}
+	_ ResponseObserver = &testResponseObserver{}
+)
+
+type testResponseObserver struct {
+	discardObserver
+	begins    int
+	responses []ResponseInfo
+}
+
+func (o *testResponseObserver) Begin(req *http.Request) {
+	o.begins++
+}
+
+func (o *testResponseObserver) CommitResponse(req *http.Request, resp ResponseInfo) {
+	o.responses = append(o.responses, resp)
+}
+
+func TestResponseObserver(t *testing.T) {
+	observer := &testResponseObserver{}
+	h := New(WithObserver(observer))
+	h.Add("/users/:id", testHandler, WithMethod(http.MethodGet), WithName("user"))
+	var tests = []struct {
+		method string
+		path   string
+		status int
+		bytes  int64
+		route  string
+		err    bool
+	}{
+		{http.MethodGet, "/users/1", http.StatusOK, 8, "user", false},
+		{http.MethodGet, "/missing", http.StatusNotFound, -1, "", true},
+		{http.MethodPost, "/users/1", http.StatusMethodNotAllowed, -1, "", true},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		h.ServeHTTP(w, req)
+		assertInt(t, "begins", observer.begins, i+1)
+		resp := observer.responses[i]
+		assertInt(t, "status", resp.Status, tt.status)
+		if tt.bytes >= 0 {
+			assertInt(t, "bytes", int(resp.Bytes), int(tt.bytes))
+		} else {
+			assertInt(t, "bytes", int(resp.Bytes), w.Body.Len())
+		}
+		name := ""
+		if resp.Route != nil {
+			name = resp.Route.Name()
+		}
+		assertString(t, "route", name, tt.route)
+		if (resp.Err != nil) != tt.err {
+			t.Fatalf("unexpected error: %v", resp.Err)
+		}
+		if resp.Start.IsZero() || resp.Start.After(time.Now()) {
+			t.Fatalf("unexpected start time: %v", resp.Start)
+		}
+	}
+}
+
+func TestResponseObserverMount(t *testing.T) {
+	observer := &testResponseObserver{}
+	child := New(WithObserver(observer))
+	child.Add("/invoices/:id", testHandler, WithName("invoice"))
+	h := New(WithObserver(observer))
+	h.Mount("/billing", child, WithName("billing"))
+	var tests = []struct {
+		path   string
+		status int
+		route  string
+		err    bool
+	}{
+		{"/billing/invoices/1", http.StatusOK, "invoice", false},
+		{"/billing/missing", http.StatusNotFound, "billing", true},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		h.ServeHTTP(w, req)
+		assertInt(t, "begins", observer.begins, i+1)
+		assertInt(t, "responses", len(observer.responses), i+1)
+		resp := observer.responses[i]
+		assertInt(t, "status", resp.Status, tt.status)
+		assertString(t, "route", resp.Route.Name(), tt.route)
+		if (resp.Err != nil) != tt.err {
+			t.Fatalf("unexpected error: %v", resp.Err)
+		}
+	}
+}
+
+type testHijacker struct {
+	*httptest.ResponseRecorder
+}
+
+func (w testHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestWrapResponseWriter(t *testing.T) {
+	var tests = []struct {
+		w                   http.ResponseWriter
+		flush, hijack, push bool
+	}{
+		{httptest.NewRecorder(), true, false, false},
+		{testHijacker{httptest.NewRecorder()}, true, true, false},
+		{struct{ http.ResponseWriter }{httptest.NewRecorder()}, false, false, false},
+	}
+	for _, tt := range tests {
+		w, rw := wrapResponseWriter(tt.w)
+		_, flush := w.(http.Flusher)
+		_, hijack := w.(http.Hijacker)
+		_, push := w.(http.Pusher)
+		if flush != tt.flush || hijack != tt.hijack || push != tt.push {
+			t.Fatalf("interfaces\nhave %v %v %v\nwant %v %v %v", flush, hijack, push, tt.flush, tt.hijack, tt.push)
+		}
+		if flush {
+			w.(http.Flusher).Flush()
+			assertInt(t, "status", rw.status(), http.StatusOK)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, err := io.WriteString(w, "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !flush {
+			assertInt(t, "status", rw.status(), http.StatusCreated)
+		}
+		assertInt(t, "bytes", int(rw.bytes), 4)
+	}
+}
+
+type testParamObserver struct {
+	discardObserver
+	params []string
+}
+
+func (o *testParamObserver) Commit(req *http.Request, t time.Time) {
+	o.params = append(o.params, Param(req, "id"))
+}
+
+func TestObserverParams(t *testing.T) {
+	observer := &testParamObserver{}
+	h := New(WithObserver(observer))
+	h.Add("/users/:id", testHandler)
+	for _, id := range []string{"1", "2"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/users/"+id, nil)
+		h.ServeHTTP(w, req)
+	}
+	assertDeepEqual(t, "params", observer.params, []string{"1", "2"})
+}
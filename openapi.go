@@ -0,0 +1,408 @@
+package mux
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIVersion is the version of the OpenAPI specification generated.
+const openAPIVersion = "3.0.3"
+
+// openAPIMediaType is the media type of documented request and response bodies.
+const openAPIMediaType = "application/json"
+
+// openAPIMethods are the methods documented for routes without methods.
+var openAPIMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// requestKey is the route metadata key of the request form.
+type requestKey struct{}
+
+// responseKey is the route metadata key of the response view for a status code.
+type responseKey int
+
+// OpenAPIDocument represents an OpenAPI 3 document.
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+}
+
+// OpenAPIInfo represents the metadata of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIPathItem represents the operations of a path keyed by lowercase method.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation represents an operation on a path.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter represents a path parameter.
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody represents a request body.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse represents a response for a status code.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType represents the schema of a request or response body.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIComponents represents the reusable schemas of an OpenAPI document.
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+// OpenAPISchema represents a JSON schema as used by OpenAPI.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// WithRequest sets the form decoded by the route for
+// the request body schema of the OpenAPI document.
+func WithRequest(form Form) RouteOption {
+	return WithMeta(requestKey{}, form)
+}
+
+// WithResponse sets the view encoded by the route with the status code
+// for the response schema of the OpenAPI document. A nil view documents
+// a response without a body.
+func WithResponse(code int, view Viewable) RouteOption {
+	return WithMeta(responseKey(code), view)
+}
+
+// OpenAPI registers a route serving the OpenAPI document of the
+// routes registered with the handler at the time of the request.
+// See Handler.OpenAPIDocument documentation for more details.
+func (h *Handler) OpenAPI(pattern string, info OpenAPIInfo, opts ...RouteOption) *Route {
+	opt := WithMethod(http.MethodGet)
+	opts = append([]RouteOption{opt}, opts...)
+	return h.Add(pattern, func(w http.ResponseWriter, req *http.Request) error {
+		doc, err := h.OpenAPIDocument(info)
+		if err != nil {
+			return err
+		}
+		return h.Encode(w, req, doc, http.StatusOK)
+	}, opts...)
+}
+
+// OpenAPIDocument returns the OpenAPI document of the registered routes.
+//
+// Patterns are converted to path templates with a path for each
+// combination of optional segments. Unnamed splats are named path.
+// Routes without methods are documented for the GET, POST, PUT,
+// PATCH and DELETE methods. The automatic HEAD method of GET routes
+// is not documented. Request schemas are derived from
+// the form set with WithRequest and response schemas from the views
+// set with WithResponse. The ErrorView schema is documented for the
+// error responses of the handler.
+func (h *Handler) OpenAPIDocument(info OpenAPIInfo) (*OpenAPIDocument, error) {
+	doc := &OpenAPIDocument{
+		OpenAPI:    openAPIVersion,
+		Info:       info,
+		Paths:      make(map[string]OpenAPIPathItem),
+		Components: OpenAPIComponents{Schemas: make(map[string]*OpenAPISchema)},
+	}
+	g := &schemaGenerator{schemas: doc.Components.Schemas, names: make(map[reflect.Type]string)}
+	errorView := g.schema(reflect.TypeOf(ErrorView{}))
+	ids := make(map[string]int)
+	err := h.WalkAll(func(r *Route) error {
+		methods := r.Methods()
+		if len(methods) == 0 {
+			methods = openAPIMethods
+		}
+		patterns, err := expand(r.Pattern())
+		if err != nil {
+			return err
+		}
+		for _, pattern := range patterns {
+			path, params := openAPIPath(pattern)
+			item, ok := doc.Paths[path]
+			if !ok {
+				item = make(OpenAPIPathItem)
+				doc.Paths[path] = item
+			}
+			for _, method := range methods {
+				if method == http.MethodHead && contains(methods, http.MethodGet) {
+					continue
+				}
+				method = strings.ToLower(method)
+				_, ok := item[method]
+				if ok {
+					continue
+				}
+				op := g.operation(r, params, errorView)
+				op.OperationID = operationID(ids, r.Name())
+				item[method] = op
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// operationID returns the unique operation ID of an operation of the
+// route named name. Operations after the first are numbered because
+// optional segments and methods document a route more than once.
+func operationID(ids map[string]int, name string) string {
+	if name == "" {
+		return ""
+	}
+	ids[name]++
+	n := ids[name]
+	if n == 1 {
+		return name
+	}
+	return name + "." + strconv.Itoa(n)
+}
+
+// operation returns the operation of the route.
+func (g *schemaGenerator) operation(r *Route, params []OpenAPIParameter, errorView *OpenAPISchema) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Parameters: params,
+		Responses:  make(map[string]OpenAPIResponse),
+	}
+	codes := []int{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotAcceptable, http.StatusInternalServerError}
+	form := r.Meta(requestKey{})
+	if form != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  openAPIContent(g.schema(reflect.TypeOf(form))),
+		}
+		codes = append(codes, http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)
+	}
+	for k, v := range r.meta {
+		code, ok := k.(responseKey)
+		if !ok {
+			continue
+		}
+		resp := OpenAPIResponse{Description: http.StatusText(int(code))}
+		if v != nil {
+			resp.Content = openAPIContent(g.schema(reflect.TypeOf(v)))
+		}
+		op.Responses[strconv.Itoa(int(code))] = resp
+	}
+	if len(op.Responses) == 0 {
+		op.Responses[strconv.Itoa(http.StatusOK)] = OpenAPIResponse{Description: http.StatusText(http.StatusOK)}
+	}
+	for _, code := range codes {
+		k := strconv.Itoa(code)
+		_, ok := op.Responses[k]
+		if ok {
+			continue
+		}
+		op.Responses[k] = OpenAPIResponse{
+			Description: http.StatusText(code),
+			Content:     openAPIContent(errorView),
+		}
+	}
+	return op
+}
+
+// openAPIPath returns the path template and path parameters of
+// the pattern without optional segments.
+func openAPIPath(pattern string) (string, []OpenAPIParameter) {
+	var buf strings.Builder
+	var params []OpenAPIParameter
+	for _, seg := range segments(pattern) {
+		switch seg.kind {
+		case ':':
+			buf.WriteString("{" + seg.text + "}")
+			params = append(params, OpenAPIParameter{
+				Name:     seg.text,
+				In:       "path",
+				Required: true,
+				Schema:   paramSchema(seg.expr),
+			})
+		case '*':
+			name := seg.text
+			if name == "*" {
+				name = "path"
+			}
+			buf.WriteString("{" + name + "}")
+			params = append(params, OpenAPIParameter{
+				Name:        name,
+				In:          "path",
+				Description: "The remaining path, which may contain slashes.",
+				Required:    true,
+				Schema:      &OpenAPISchema{Type: "string"},
+			})
+		default:
+			buf.WriteString(seg.text)
+		}
+	}
+	return buf.String(), params
+}
+
+// paramSchema returns the schema of a param constraint.
+func paramSchema(expr string) *OpenAPISchema {
+	switch expr {
+	case "":
+		return &OpenAPISchema{Type: "string"}
+	case "int":
+		return &OpenAPISchema{Type: "integer"}
+	case "uuid":
+		return &OpenAPISchema{Type: "string", Format: "uuid"}
+	}
+	s, ok := constraints[expr]
+	if !ok {
+		s = expr
+	}
+	return &OpenAPISchema{Type: "string", Pattern: "^(?:" + s + ")$"}
+}
+
+// openAPIContent returns the content of a body with the schema.
+func openAPIContent(schema *OpenAPISchema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{openAPIMediaType: {Schema: schema}}
+}
+
+// schemaGenerator derives schemas from types, storing the schemas
+// of named struct types as reusable components.
+type schemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of the type. A reference to the component
+// is returned for named struct types.
+func (g *schemaGenerator) schema(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &OpenAPISchema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.name(t)
+			g.names[t] = name
+			g.schemas[name] = g.object(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	return &OpenAPISchema{}
+}
+
+// name returns a unique component name for the named type.
+func (g *schemaGenerator) name(t reflect.Type) string {
+	name := t.Name()
+	_, ok := g.schemas[name]
+	for i := 2; ok; i++ {
+		name = t.Name() + strconv.Itoa(i)
+		_, ok = g.schemas[name]
+	}
+	// Reserve the name for recursive types.
+	g.schemas[name] = nil
+	return name
+}
+
+// object returns the object schema of the struct type. Fields are named
+// by their json struct tags. Fields without the omitempty option are
+// required. The fields of embedded structs are promoted.
+func (g *schemaGenerator) object(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	g.fields(s, t)
+	return s
+}
+
+func (g *schemaGenerator) fields(s *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		j := strings.IndexByte(tag, ',')
+		if j >= 0 {
+			name, opts = tag[:j], tag[j:]
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			g.fields(s, ft)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schema(f.Type)
+		if !strings.Contains(opts, ",omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
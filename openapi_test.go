@@ -0,0 +1,117 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testUserForm struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func (f *testUserForm) Validate() error {
+	return nil
+}
+
+type testUserView struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	secret  string
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	h := New()
+	h.Add("/users", testHandler, WithMethod(http.MethodPost), WithName("users.create"),
+		WithRequest(&testUserForm{}), WithResponse(http.StatusCreated, testUserView{}))
+	h.Add("/users/:id{int}(/:tab)", testHandler, WithMethod(http.MethodGet), WithName("users.show"),
+		WithResponse(http.StatusOK, &testUserView{}))
+	h.Add("/files/*", testHandler, WithMethod(http.MethodGet, http.MethodDelete))
+	h.Add("/any", testHandler)
+	doc, err := h.OpenAPIDocument(OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	paths := make([]string, 0)
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	assertInt(t, "paths", len(paths), 5)
+	create := doc.Paths["/users"]["post"]
+	if create == nil {
+		t.Fatalf("missing operation 'post /users'")
+	}
+	assertString(t, "operationId", create.OperationID, "users.create")
+	body := create.RequestBody.Content["application/json"].Schema
+	assertString(t, "request", body.Ref, "#/components/schemas/testUserForm")
+	assertString(t, "response", create.Responses["201"].Content["application/json"].Schema.Ref, "#/components/schemas/testUserView")
+	for _, code := range []string{"400", "404", "405", "406", "415", "422", "500"} {
+		resp, ok := create.Responses[code]
+		if !ok {
+			t.Fatalf("missing response '%s'", code)
+		}
+		assertString(t, "error", resp.Content["application/json"].Schema.Ref, "#/components/schemas/ErrorView")
+	}
+	show := doc.Paths["/users/{id}/{tab}"]["get"]
+	if show == nil {
+		t.Fatalf("missing operation 'get /users/{id}/{tab}'")
+	}
+	assertDeepEqual(t, "params", show.Parameters[0].Schema, &OpenAPISchema{Type: "integer"})
+	assertInt(t, "params", len(doc.Paths["/users/{id}"]["get"].Parameters), 1)
+	_, ok := doc.Paths["/users/{id}"]["head"]
+	if ok {
+		t.Fatalf("automatic HEAD should not be documented")
+	}
+	_, ok = doc.Paths["/users/{id}"]["get"].Responses["415"]
+	if ok {
+		t.Fatalf("decode errors should not be documented without a request")
+	}
+	assertString(t, "operationId", show.OperationID, "users.show")
+	assertString(t, "operationId", doc.Paths["/users/{id}"]["get"].OperationID, "users.show.2")
+	ids := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if op.OperationID == "" {
+				continue
+			}
+			if ids[op.OperationID] {
+				t.Fatalf("duplicate operationId '%s' for '%s %s'", op.OperationID, method, path)
+			}
+			ids[op.OperationID] = true
+		}
+	}
+	assertInt(t, "methods", len(doc.Paths["/files/{path}"]), 2)
+	assertInt(t, "methods", len(doc.Paths["/any"]), len(openAPIMethods))
+	user := doc.Components.Schemas["testUserForm"]
+	assertDeepEqual(t, "required", user.Required, []string{"name"})
+	assertDeepEqual(t, "tags", user.Properties["tags"], &OpenAPISchema{Type: "array", Items: &OpenAPISchema{Type: "string"}})
+	view := doc.Components.Schemas["testUserView"]
+	assertInt(t, "properties", len(view.Properties), 3)
+	assertDeepEqual(t, "created", view.Properties["created"], &OpenAPISchema{Type: "string", Format: "date-time"})
+}
+
+func TestOpenAPI(t *testing.T) {
+	h := New()
+	h.OpenAPI("/openapi.json", OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+	h.Add("/users/:id", testHandler, WithMethod(http.MethodGet))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	h.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusOK)
+	var doc map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDeepEqual(t, "openapi", doc["openapi"], "3.0.3")
+	paths := doc["paths"].(map[string]interface{})
+	_, ok := paths["/users/{id}"]
+	if !ok {
+		t.Fatalf("missing path '/users/{id}'")
+	}
+}
@@ -2,6 +2,8 @@ package mux
 
 import (
 	"net/http"
+	"net/url"
+	"time"
 
 	"golang.org/x/text/language"
 )
@@ -16,6 +18,21 @@ func WithRouter(router Router) Option {
 	}
 }
 
+// WithMatchMode sets the path matching mode of the default router.
+// The mode is ignored if the router is set with WithRouter.
+func WithMatchMode(mode MatchMode) Option {
+	return func(h *Handler) {
+		h.mode = mode
+	}
+}
+
+// WithBaseURL sets the base URL used to build absolute route URLs.
+func WithBaseURL(base *url.URL) Option {
+	return func(h *Handler) {
+		h.base = base
+	}
+}
+
 // WithLocales sets the supported language tags.
 func WithLocales(tags []language.Tag) Option {
 	return func(h *Handler) {
@@ -37,6 +54,24 @@ func WithEncoder(fn EncoderFunc) Option {
 	}
 }
 
+// WithErrorEncoder sets the encoder negotiation function for error views.
+// Defaults to the encoder negotiation function set with WithEncoder.
+func WithErrorEncoder(fn EncoderFunc) Option {
+	return func(h *Handler) {
+		h.errors = fn
+	}
+}
+
+// WithProblemDetails sets the error resolver and error encoder to respond
+// with RFC 9457 problem details. See NewProblemResolver and
+// NewProblemEncoder documentation for more details.
+func WithProblemDetails() Option {
+	return func(h *Handler) {
+		h.resolver = NewProblemResolver()
+		h.errors = NewProblemEncoder()
+	}
+}
+
 // WithResolver sets the error resolver.
 func WithResolver(resolver Resolver) Option {
 	return func(h *Handler) {
@@ -65,6 +100,27 @@ func WithObserver(observer Observer) Option {
 	}
 }
 
+// WithCanonicalRedirect enables redirects to the canonical URL for
+// requests that do not match a route, but would match if the request
+// path was cleaned or had the trailing slash added or removed.
+//
+// GET and HEAD requests are redirected with a 301 Moved Permanently.
+// Requests with other methods are redirected with a 308 Permanent
+// Redirect to preserve the method and body. The query is preserved.
+func WithCanonicalRedirect() Option {
+	return func(h *Handler) {
+		h.redirect = true
+	}
+}
+
+// WithDefaultTimeout sets the timeout of routes without a timeout set
+// with WithTimeout. See WithTimeout documentation for more details.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
 // RouteOption represents a functional option for configuration.
 type RouteOption func(*Route)
 
@@ -75,6 +131,16 @@ func WithName(name string) RouteOption {
 	}
 }
 
+// WithHost sets the host pattern for which the route is valid.
+//
+// Host patterns are dot-separated labels where a label of the form
+// {name} matches any single label and is stored as a route parameter.
+func WithHost(pattern string) RouteOption {
+	return func(r *Route) {
+		r.host = pattern
+	}
+}
+
 // WithMethod sets the methods for which the route is valid.
 func WithMethod(method ...string) RouteOption {
 	return func(r *Route) {
@@ -94,3 +160,26 @@ func WithMiddleware(middleware ...func(http.Handler) http.Handler) RouteOption {
 		r.middleware = append(r.middleware, middleware...)
 	}
 }
+
+// WithMeta sets the route metadata value for the key. Keys should be of
+// an unexported type defined by the package using the metadata to avoid
+// collisions, as with context.WithValue.
+func WithMeta(key, value interface{}) RouteOption {
+	return func(r *Route) {
+		if r.meta == nil {
+			r.meta = make(map[interface{}]interface{})
+		}
+		r.meta[key] = value
+	}
+}
+
+// WithTimeout sets the duration after which the request context of the
+// route is done. If the deadline passes before the response is committed,
+// ErrTimeout is resolved with a 503 Service Unavailable error and further
+// writes by the route are discarded. Errors wrapping context.DeadlineExceeded
+// returned by the route are resolved with a 504 Gateway Timeout error.
+func WithTimeout(d time.Duration) RouteOption {
+	return func(r *Route) {
+		r.timeout = d
+	}
+}
@@ -0,0 +1,197 @@
+package mux
+
+import "fmt"
+
+// expand returns the patterns expanded from the optional segments of the
+// pattern, ordered from the most to the fewest optional segments present.
+// Optional segments are enclosed in parentheses and may be nested.
+func expand(pattern string) ([]string, error) {
+	i, j := optionalIndex(pattern)
+	if i < 0 {
+		if j >= 0 {
+			return nil, fmt.Errorf("mux: unbalanced optional segment '%s'", pattern)
+		}
+		return []string{pattern}, nil
+	}
+	if j < 0 {
+		return nil, fmt.Errorf("mux: unterminated optional segment '%s'", pattern)
+	}
+	inner, err := expand(pattern[i+1 : j])
+	if err != nil {
+		return nil, err
+	}
+	rest, err := expand(pattern[j+1:])
+	if err != nil {
+		return nil, err
+	}
+	patterns := make([]string, 0, (len(inner)+1)*len(rest))
+	for _, a := range inner {
+		for _, b := range rest {
+			patterns = append(patterns, pattern[:i]+a+b)
+		}
+	}
+	for _, b := range rest {
+		patterns = append(patterns, pattern[:i]+b)
+	}
+	return patterns, nil
+}
+
+// optionalIndex returns the indexes of the first optional segment
+// parentheses that are not within a param constraint. The index of
+// the closing parenthesis is negative if it is unterminated. The index
+// of the opening parenthesis is negative if there is no optional
+// segment, in which case the index of a stray closing parenthesis is
+// returned if one exists.
+func optionalIndex(pattern string) (int, int) {
+	i := -1
+	depth := 0
+	for j := 0; j < len(pattern); j++ {
+		switch pattern[j] {
+		case '{':
+			n := closeBrace(pattern[j:])
+			if n < 0 {
+				return i, -1
+			}
+			j += n
+		case '(':
+			if depth == 0 {
+				i = j
+			}
+			depth++
+		case ')':
+			if depth == 0 {
+				return -1, j
+			}
+			depth--
+			if depth == 0 {
+				return i, j
+			}
+		}
+	}
+	return i, -1
+}
+
+// paramKeys returns the param and splat names of the pattern in order.
+func paramKeys(pattern string) []string {
+	keys := make([]string, 0)
+	for {
+		pattern = pattern[nextParam(pattern):]
+		if pattern == "" {
+			return keys
+		}
+		var key string
+		var n int
+		switch pattern[0] {
+		case ':':
+			key, _, n = parseParam(pattern)
+		case '*':
+			key, n = parseSplat(pattern)
+		}
+		if n < 0 {
+			return keys
+		}
+		keys = append(keys, key)
+		pattern = pattern[n:]
+	}
+}
+
+// nextParam returns the index of the next param or splat in the pattern.
+// Params and splats begin the pattern or immediately follow a break.
+func nextParam(pattern string) int {
+	for i := 0; i < len(pattern); i++ {
+		if isParam(pattern[i]) && (i == 0 || isBreak(pattern[i-1])) {
+			return i
+		}
+	}
+	return len(pattern)
+}
+
+// parseSplat parses the splat at the start of pattern and returns the
+// splat name and the length of the splat. The name of an unnamed splat
+// is the asterisk.
+func parseSplat(pattern string) (name string, n int) {
+	i := 1
+	for i < len(pattern) && !isBreak(pattern[i]) {
+		i++
+	}
+	name = pattern[1:i]
+	if name == "" {
+		name = "*"
+	}
+	return name, i
+}
+
+// parseParam parses the param at the start of pattern and returns the
+// param name, the constraint expression and the length of the param.
+// A negative length is returned if the constraint is unterminated.
+func parseParam(pattern string) (name, expr string, n int) {
+	i := 1
+	for i < len(pattern) && pattern[i] != '{' && !isBreak(pattern[i]) {
+		i++
+	}
+	name = pattern[1:i]
+	if i == len(pattern) || pattern[i] != '{' {
+		return name, "", i
+	}
+	j := closeBrace(pattern[i:])
+	if j < 0 {
+		return name, "", -1
+	}
+	return name, pattern[i+1 : i+j], i + j + 1
+}
+
+// closeBrace returns the index of the brace closing the opening brace
+// at the start of s, or -1 if it is unterminated. Nested braces and
+// escaped characters are skipped.
+func closeBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// segment represents a static, param or splat segment of a pattern.
+type segment struct {
+	kind byte
+	text string
+	expr string
+}
+
+// segments returns the segments of the pattern without optional segments.
+// The kind of a static segment is zero and the text is the static text.
+// The kind of a param or splat segment is its prefix and the text is the
+// param name.
+func segments(pattern string) []segment {
+	var rv []segment
+	for pattern != "" {
+		switch pattern[0] {
+		case ':':
+			name, expr, n := parseParam(pattern)
+			if n < 0 {
+				return rv
+			}
+			rv = append(rv, segment{kind: ':', text: name, expr: expr})
+			pattern = pattern[n:]
+		case '*':
+			name, n := parseSplat(pattern)
+			rv = append(rv, segment{kind: '*', text: name})
+			pattern = pattern[n:]
+		default:
+			i := nextParam(pattern)
+			rv = append(rv, segment{text: pattern[:i]})
+			pattern = pattern[i:]
+		}
+	}
+	return rv
+}
@@ -0,0 +1,51 @@
+package mux
+
+import "testing"
+
+func TestExpand(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		want    []string
+	}{
+		{"/docs", []string{"/docs"}},
+		{"/docs(/:version)", []string{"/docs/:version", "/docs"}},
+		{"/a(/b(/c))", []string{"/a/b/c", "/a/b", "/a"}},
+		{"/a(/b)(/c)", []string{"/a/b/c", "/a/b", "/a/c", "/a"}},
+		{"/:id{(a|b)}(.json)", []string{"/:id{(a|b)}.json", "/:id{(a|b)}"}},
+	}
+	for _, tt := range tests {
+		have, err := expand(tt.pattern)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertDeepEqual(t, "expand", have, tt.want)
+	}
+}
+
+func TestExpandInvalid(t *testing.T) {
+	var tests = []string{
+		"/docs(/:version",
+		"/docs/:version)",
+		"/docs(/(:version)",
+	}
+	for _, pattern := range tests {
+		_, err := expand(pattern)
+		if err == nil {
+			t.Fatalf("should not expand invalid pattern '%s'", pattern)
+		}
+	}
+}
+
+func TestParamKeys(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		want    []string
+	}{
+		{"/", []string{}},
+		{"/:a/:b{int}/*", []string{"a", "b", "*"}},
+		{"/repo/*path/blob/:file.:ext", []string{"path", "file", "ext"}},
+	}
+	for _, tt := range tests {
+		assertDeepEqual(t, "keys", paramKeys(tt.pattern), tt.want)
+	}
+}
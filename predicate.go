@@ -0,0 +1,117 @@
+package mux
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// predicate represents a request condition of a route and the
+// error of the request if the condition is not satisfied.
+type predicate struct {
+	fn  func(req *http.Request) bool
+	err error
+}
+
+// WithHeader sets a request header condition for the route. The route
+// matches if a value of the header equals the value or, if the value is
+// empty, if the header is present. Routes with predicates may share a
+// pattern and method and are matched in order of registration.
+func WithHeader(key, value string) RouteOption {
+	key = http.CanonicalHeaderKey(key)
+	fn := func(req *http.Request) bool {
+		values, ok := req.Header[key]
+		if !ok {
+			return false
+		}
+		return value == "" || contains(values, value)
+	}
+	return withPredicate(fn, ErrNotFound)
+}
+
+// WithQuery sets a query parameter condition for the route. The route
+// matches if a value of the query parameter equals the value or, if the
+// value is empty, if the query parameter is present. Routes with
+// predicates may share a pattern and method and are matched in order
+// of registration.
+func WithQuery(key, value string) RouteOption {
+	fn := func(req *http.Request) bool {
+		values, ok := req.URL.Query()[key]
+		if !ok {
+			return false
+		}
+		return value == "" || contains(values, value)
+	}
+	return withPredicate(fn, ErrNotFound)
+}
+
+// WithConsumes sets the media types of the request Content-Type header
+// for the route. Media types may be ranges such as application/*. If no
+// route of the pattern and method matches and this is the only unmet
+// condition, the request fails with ErrDecodeContentType, responding
+// with a 415 Unsupported Media Type error.
+func WithConsumes(mediaTypes ...string) RouteOption {
+	fn := func(req *http.Request) bool {
+		v, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+		for _, mediaType := range mediaTypes {
+			if matchMediaType(mediaType, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return withPredicate(fn, ErrDecodeContentType)
+}
+
+// WithProduces sets the media types of the response for the route.
+// The route matches if the request Accept header accepts one of the
+// media types. A request without an Accept header accepts any media
+// type. If no route of the pattern and method matches and this is the
+// only unmet condition, the request fails with ErrEncodeMatch,
+// responding with a 406 Not Acceptable error.
+func WithProduces(mediaTypes ...string) RouteOption {
+	fn := func(req *http.Request) bool {
+		accept := req.Header.Get("Accept")
+		if accept == "" {
+			return true
+		}
+		for _, mediaType := range mediaTypes {
+			if accepts(accept, mediaType) {
+				return true
+			}
+		}
+		return false
+	}
+	return withPredicate(fn, ErrEncodeMatch)
+}
+
+func withPredicate(fn func(req *http.Request) bool, err error) RouteOption {
+	return func(r *Route) {
+		r.predicates = append(r.predicates, predicate{fn: fn, err: err})
+	}
+}
+
+// test returns the error of the first unmet predicate of the route.
+func (r *Route) test(req *http.Request) error {
+	for _, p := range r.predicates {
+		if !p.fn(req) {
+			return p.err
+		}
+	}
+	return nil
+}
+
+// matchMediaType reports whether the media type matches the media range.
+func matchMediaType(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || strings.EqualFold(mediaRange, mediaType) {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		i := len(mediaRange) - 1
+		return len(mediaType) > i && strings.EqualFold(mediaRange[:i], mediaType[:i])
+	}
+	return false
+}
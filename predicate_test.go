@@ -0,0 +1,127 @@
+package mux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPredicates(t *testing.T) {
+	h := New()
+	handler := func(body string) HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) error {
+			_, err := io.WriteString(w, body)
+			return err
+		}
+	}
+	h.Add("/users", handler("v2"), WithMethod(http.MethodGet), WithProduces("application/vnd.acme.v2+json"))
+	h.Add("/users", handler("csv"), WithMethod(http.MethodGet), WithQuery("format", "csv"))
+	h.Add("/users", handler("v1"), WithMethod(http.MethodGet), WithProduces("application/json"))
+	h.Add("/users", handler("beta"), WithMethod(http.MethodPost), WithHeader("X-Beta", ""), WithConsumes("application/*"))
+	h.Add("/users", handler("json"), WithMethod(http.MethodPost), WithConsumes("application/json"))
+	var tests = []struct {
+		method  string
+		url     string
+		headers map[string]string
+		code    int
+		body    string
+	}{
+		{http.MethodGet, "/users", map[string]string{"Accept": "application/vnd.acme.v2+json"}, http.StatusOK, "v2"},
+		{http.MethodGet, "/users", map[string]string{"Accept": "application/json"}, http.StatusOK, "v1"},
+		{http.MethodGet, "/users", map[string]string{"Accept": "application/vnd.acme.v2+json;q=0, */*"}, http.StatusOK, "v1"},
+		{http.MethodGet, "/users", nil, http.StatusOK, "v2"},
+		{http.MethodGet, "/users?format=csv", map[string]string{"Accept": "text/csv"}, http.StatusOK, "csv"},
+		{http.MethodGet, "/users", map[string]string{"Accept": "text/html"}, http.StatusNotAcceptable, ""},
+		{http.MethodPost, "/users", map[string]string{"Content-Type": "application/json", "X-Beta": "1"}, http.StatusOK, "beta"},
+		{http.MethodPost, "/users", map[string]string{"Content-Type": "application/json; charset=utf-8"}, http.StatusOK, "json"},
+		{http.MethodPost, "/users", map[string]string{"Content-Type": "text/plain"}, http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.url, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		h.ServeHTTP(w, req)
+		assertInt(t, tt.method+" "+tt.url+" status code", w.Code, tt.code)
+		if tt.body != "" {
+			assertString(t, "body", w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestPredicatesFallback(t *testing.T) {
+	h := New()
+	h.Add("/users", func(w http.ResponseWriter, req *http.Request) error {
+		_, err := io.WriteString(w, "csv")
+		return err
+	}, WithMethod(http.MethodGet), WithQuery("format", "csv"))
+	h.Add("/users", func(w http.ResponseWriter, req *http.Request) error {
+		_, err := io.WriteString(w, "any")
+		return err
+	})
+	var tests = []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{http.MethodGet, "/users?format=csv", "csv"},
+		{http.MethodGet, "/users", "any"},
+		{http.MethodPost, "/users", "any"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.url, nil)
+		h.ServeHTTP(w, req)
+		assertInt(t, tt.method+" "+tt.url+" status code", w.Code, http.StatusOK)
+		assertString(t, "body", w.Body.String(), tt.body)
+	}
+}
+
+func TestPredicatesDuplicate(t *testing.T) {
+	tree := &tree{}
+	err := tree.Add(NewRoute("/", nil, WithHeader("X-Version", "2")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tree.Add(NewRoute("/", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tree.Add(NewRoute("/", nil, WithHeader("X-Version", "3")))
+	if err == nil {
+		t.Fatalf("should not add route after route without predicates")
+	}
+}
+
+func TestPredicatesValidate(t *testing.T) {
+	h := New()
+	h.Add("/", testHandler, WithHeader("X-Version", "2"))
+	h.Add("/", testHandler)
+	diagnostics := h.Validate()
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diagnostics)
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	var tests = []struct {
+		accept    string
+		mediaType string
+		want      bool
+	}{
+		{"application/json", "application/json", true},
+		{"application/*", "application/json", true},
+		{"*/*;q=0.1", "application/json", true},
+		{"text/html, application/json;q=0", "application/json", false},
+		{"text/html;;, application/json", "application/json", true},
+		{"text/html", "application/json", false},
+	}
+	for _, tt := range tests {
+		have := accepts(tt.accept, tt.mediaType)
+		if have != tt.want {
+			t.Fatalf("accepts '%s' '%s'\nhave %v\nwant %v", tt.accept, tt.mediaType, have, tt.want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package mux
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// problemNamespace is the XML namespace of problem details.
+const problemNamespace = "urn:ietf:rfc:7807"
+
+// ProblemView is an error view of problem details as defined by RFC 9457.
+//
+// The extension members are encoded alongside the standard members.
+// Extension members with the name of a standard member are ignored.
+type ProblemView struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// Error implements the error interface.
+func (v ProblemView) Error() string {
+	if v.Detail == "" {
+		return v.Title
+	}
+	return v.Detail
+}
+
+// StatusCode implements the mux.Error interface.
+func (v ProblemView) StatusCode() int {
+	return v.Status
+}
+
+// NewProblemView returns a new ProblemView of the about:blank problem
+// type for the status code. The detail is the supplementary message text
+// returned by ErrorText and the instance is the escaped request path,
+// including the prefix of any mounts. The request identifier is set as
+// the request_id extension member and the allowed methods of
+// ErrMethodNotAllowed errors are set as the allow extension member.
+func NewProblemView(req *http.Request, code int, err error) ProblemView {
+	v := ProblemView{
+		Type:       "about:blank",
+		Title:      http.StatusText(code),
+		Status:     code,
+		Detail:     ErrorText(code, err),
+		Instance:   mountPrefix(req) + req.URL.EscapedPath(),
+		Extensions: map[string]interface{}{"request_id": RequestID(req)},
+	}
+	allowed, ok := err.(ErrMethodNotAllowed)
+	if ok {
+		v.Extensions["allow"] = []string(allowed)
+	}
+	return v
+}
+
+// NewProblemResolver returns a Resolver that resolves errors
+// to a ProblemView. See NewProblemView documentation for details.
+func NewProblemResolver() Resolver {
+	return ResolverFunc(func(req *http.Request, code int, err error) Error {
+		return NewProblemView(req, code, err)
+	})
+}
+
+// NewProblemEncoder returns an EncoderFunc negotiating the
+// application/problem+json and application/problem+xml media types
+// from the request Accept header. Requests accepting JSON or XML
+// are negotiated to the problem media type of the same format.
+// Requests that do not accept either format are encoded as
+// application/problem+json so that the error is always delivered.
+func NewProblemEncoder() EncoderFunc {
+	pj := &problemJSONEncoder{}
+	px := &problemXMLEncoder{}
+	fn := NewAcceptEncoder(map[string]Encoder{
+		"":                         pj,
+		"*/*":                      pj,
+		"application/*":            pj,
+		"application/json":         pj,
+		"application/problem+json": pj,
+		"application/xml":          px,
+		"text/xml":                 px,
+		"application/problem+xml":  px,
+	}, "application/problem+json", "application/problem+xml")
+	return func(req *http.Request) (Encoder, error) {
+		e, err := fn(req)
+		if err != nil {
+			return pj, nil
+		}
+		return e, nil
+	}
+}
+
+// members returns the members of the problem details with the
+// extension members, sorted by name.
+func (v ProblemView) members() ([]string, map[string]interface{}) {
+	m := make(map[string]interface{}, len(v.Extensions)+5)
+	for k, ext := range v.Extensions {
+		m[k] = ext
+	}
+	m["type"] = v.Type
+	m["title"] = v.Title
+	m["status"] = v.Status
+	delete(m, "detail")
+	delete(m, "instance")
+	if v.Detail != "" {
+		m["detail"] = v.Detail
+	}
+	if v.Instance != "" {
+		m["instance"] = v.Instance
+	}
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names, m
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (v ProblemView) MarshalJSON() ([]byte, error) {
+	_, m := v.members()
+	return json.Marshal(m)
+}
+
+// MarshalXML implements the xml.Marshaler interface. Arrays are
+// encoded with an i element for each item as defined by RFC 9457.
+func (v ProblemView) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: problemNamespace, Local: "problem"}}
+	err := e.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	names, m := v.members()
+	for _, name := range names {
+		err := encodeProblemMember(e, name, m[name])
+		if err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// MarshalCSV implements the CSVMarshaler interface. Arrays
+// are encoded as a single value with comma separated items.
+func (v ProblemView) MarshalCSV() ([][]string, error) {
+	names, m := v.members()
+	record := make([]string, len(names))
+	for i, name := range names {
+		rv := reflect.ValueOf(m[name])
+		if !isList(rv) {
+			s, err := formatCSV(rv)
+			if err != nil {
+				return nil, err
+			}
+			record[i] = s
+			continue
+		}
+		items := make([]string, rv.Len())
+		for j := range items {
+			s, err := formatCSV(rv.Index(j))
+			if err != nil {
+				return nil, err
+			}
+			items[j] = s
+		}
+		record[i] = strings.Join(items, ",")
+	}
+	return [][]string{names, record}, nil
+}
+
+// encodeProblemMember encodes a member of a problem as an element as
+// described by RFC 7807 Appendix A. Array items are encoded as i child
+// elements and object members as child elements in sorted order.
+func encodeProblemMember(e *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch {
+	case rv.Kind() == reflect.Map:
+		err := e.EncodeToken(start)
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]reflect.Value, rv.Len())
+		for _, k := range rv.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			keys = append(keys, key)
+			values[key] = rv.MapIndex(k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			err := encodeProblemMember(e, key, values[key].Interface())
+			if err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case isList(rv):
+		err := e.EncodeToken(start)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			err := encodeProblemMember(e, "i", rv.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	}
+	return e.EncodeElement(value, start)
+}
+
+type problemJSONEncoder struct{}
+
+func (*problemJSONEncoder) Encode(w io.Writer, view Viewable) error {
+	return json.NewEncoder(w).Encode(view)
+}
+
+func (*problemJSONEncoder) Headers() http.Header {
+	return http.Header{"Content-Type": []string{"application/problem+json"}}
+}
+
+type problemXMLEncoder struct{}
+
+func (*problemXMLEncoder) Encode(w io.Writer, view Viewable) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(view)
+}
+
+func (*problemXMLEncoder) Headers() http.Header {
+	return http.Header{"Content-Type": []string{"application/problem+xml; charset=utf-8"}}
+}
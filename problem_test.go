@@ -0,0 +1,141 @@
+package mux
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var _ Error = ProblemView{}
+
+func TestProblemDetails(t *testing.T) {
+	h := New(WithProblemDetails())
+	h.Add("/users", testHandler, WithMethod(http.MethodGet))
+	var tests = []struct {
+		method      string
+		path        string
+		accept      string
+		code        int
+		contentType string
+	}{
+		{http.MethodGet, "/missing", "application/json", http.StatusNotFound, "application/problem+json"},
+		{http.MethodGet, "/missing", "text/html", http.StatusNotFound, "application/problem+json"},
+		{http.MethodGet, "/missing", "application/problem+xml", http.StatusNotFound, "application/problem+xml; charset=utf-8"},
+		{http.MethodPost, "/users", "application/json", http.StatusMethodNotAllowed, "application/problem+json"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		h.ServeHTTP(w, req)
+		assertInt(t, "status code", w.Code, tt.code)
+		assertString(t, "content type", w.Header().Get("Content-Type"), tt.contentType)
+		if strings.HasSuffix(tt.contentType, "json") {
+			var v map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &v)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertDeepEqual(t, "type", v["type"], "about:blank")
+			assertDeepEqual(t, "status", v["status"], float64(tt.code))
+			assertDeepEqual(t, "instance", v["instance"], tt.path)
+		}
+	}
+}
+
+func TestProblemDetailsInstance(t *testing.T) {
+	h := New(WithProblemDetails())
+	h.Mount("/api", New(WithProblemDetails()))
+	var tests = []struct {
+		path     string
+		instance string
+	}{
+		{"/missing", "/missing"},
+		{"/a%20b", "/a%20b"},
+		{"/api/x", "/api/x"},
+		{"/api/a%2Fb", "/api/a%2Fb"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept", "application/json")
+		h.ServeHTTP(w, req)
+		assertInt(t, "status code", w.Code, http.StatusNotFound)
+		var v map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &v)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertDeepEqual(t, "instance", v["instance"], tt.instance)
+	}
+}
+
+func TestProblemViewMarshalJSON(t *testing.T) {
+	v := ProblemView{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Extensions: map[string]interface{}{"balance": 30, "status": 200},
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"balance":30,"detail":"Your current balance is 30, but that costs 50.","status":403,` +
+		`"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`
+	assertString(t, "json", string(b), want)
+}
+
+func TestProblemViewMarshalXML(t *testing.T) {
+	v := ProblemView{
+		Type:       "about:blank",
+		Title:      "Method Not Allowed",
+		Status:     http.StatusMethodNotAllowed,
+		Extensions: map[string]interface{}{"allow": []string{"GET", "HEAD"}},
+	}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<problem xmlns="urn:ietf:rfc:7807"><allow><i>GET</i><i>HEAD</i></allow>` +
+		`<status>405</status><title>Method Not Allowed</title><type>about:blank</type></problem>`
+	assertString(t, "xml", string(b), want)
+}
+
+func TestProblemViewMarshalXMLObject(t *testing.T) {
+	v := ProblemView{
+		Type:   "about:blank",
+		Title:  "Unprocessable Entity",
+		Status: http.StatusUnprocessableEntity,
+		Extensions: map[string]interface{}{
+			"errors": []map[string]interface{}{
+				{"pointer": "#/name", "detail": "required"},
+			},
+			"limits": map[string]int{"max": 10, "min": 1},
+		},
+	}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `<problem xmlns="urn:ietf:rfc:7807">` +
+		`<errors><i><detail>required</detail><pointer>#/name</pointer></i></errors>` +
+		`<limits><max>10</max><min>1</min></limits>` +
+		`<status>422</status><title>Unprocessable Entity</title><type>about:blank</type></problem>`
+	assertString(t, "xml", string(b), want)
+	h := New(WithProblemDetails())
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		return v
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/problem+xml")
+	h.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusUnprocessableEntity)
+	assertString(t, "content type", w.Header().Get("Content-Type"), "application/problem+xml; charset=utf-8")
+	assertString(t, "body", w.Body.String(), xml.Header+want)
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package mux
+
+// raceEnabled reports whether the race detector is enabled. Pools drop
+// items at random with the race detector, so allocations are not tested.
+const raceEnabled = true
@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// canonical returns a redirect to the canonical URL of the request if
+// a route matches the cleaned request path or the request path with
+// the trailing slash toggled. The error ErrNotFound is returned if
+// there is no match.
+func (h *Handler) canonical(req *http.Request) error {
+	p := req.URL.EscapedPath()
+	if p == "" {
+		return ErrNotFound
+	}
+	c := path.Clean(p)
+	if strings.HasSuffix(p, "/") && c != "/" {
+		c += "/"
+	}
+	for _, candidate := range []string{c, toggleSlash(c)} {
+		if candidate == p {
+			continue
+		}
+		u := *req.URL
+		u.RawPath = candidate
+		u.Path = candidate
+		v, err := url.PathUnescape(candidate)
+		if err == nil {
+			u.Path = v
+		}
+		r := new(http.Request)
+		*r = *req
+		r.URL = &u
+		_, params, err := h.router.Match(r)
+		h.release(params)
+		redirect, ok := err.(ErrRedirect)
+		if ok {
+			return redirect
+		}
+		if err != nil {
+			continue
+		}
+		location := candidate
+		if req.URL.RawQuery != "" {
+			location += "?" + req.URL.RawQuery
+		}
+		return ErrRedirect{URL: location, Code: redirectCode(req.Method)}
+	}
+	return ErrNotFound
+}
+
+// mountRedirect prepends the mount prefix of the request to the
+// location of a canonical redirect by the router or Handler.
+func mountRedirect(req *http.Request, err error) error {
+	redirect, ok := err.(ErrRedirect)
+	if !ok {
+		return err
+	}
+	redirect.URL = mountPrefix(req) + redirect.URL
+	return redirect
+}
+
+// toggleSlash adds or removes the trailing slash of the path.
+func toggleSlash(p string) string {
+	if p == "/" {
+		return p
+	}
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
+
+// redirectCode returns the permanent redirect status code for the method.
+func redirectCode(method string) int {
+	if method == http.MethodGet || method == http.MethodHead {
+		return http.StatusMovedPermanently
+	}
+	return http.StatusPermanentRedirect
+}
@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalRedirect(t *testing.T) {
+	var tests = []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/users/", http.StatusMovedPermanently, "/users"},
+		{http.MethodHead, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{http.MethodPost, "/users/", http.StatusPermanentRedirect, "/users"},
+		{http.MethodGet, "/posts", http.StatusMovedPermanently, "/posts/"},
+		{http.MethodGet, "/a//b/../users", http.StatusMovedPermanently, "/a/users"},
+		{http.MethodGet, "/a/./users/", http.StatusMovedPermanently, "/a/users"},
+		{http.MethodGet, "/missing/", http.StatusNotFound, ""},
+		{http.MethodGet, "/users", http.StatusOK, ""},
+	}
+	h := New(WithCanonicalRedirect())
+	h.Add("/users", testHandler, WithMethod(http.MethodGet, http.MethodPost))
+	h.Add("/posts/", testHandler)
+	h.Add("/a/users", testHandler)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		h.ServeHTTP(w, req)
+		resp := w.Result()
+		resp.Body.Close()
+		assertStatus(t, resp, tt.code)
+		assertHeader(t, resp, "Location", tt.location)
+	}
+}
+
+func TestCanonicalRedirectDisabled(t *testing.T) {
+	h := New()
+	h.Add("/users", testHandler)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	h.ServeHTTP(w, req)
+	resp := w.Result()
+	resp.Body.Close()
+	assertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestCanonicalRedirectMount(t *testing.T) {
+	var tests = []struct {
+		path     string
+		location string
+	}{
+		{"/t/acme/app/users/", "/t/acme/app/users"},
+		{"/t/acme/app/a%20b/?page=2", "/t/acme/app/a%20b?page=2"},
+		{"/t/acme/app/v1/posts", "/t/acme/app/v1/posts/"},
+		{"/t/acme/fold/Users", "/t/acme/fold/users"},
+	}
+	grandchild := New(WithCanonicalRedirect())
+	grandchild.Add("/posts/", testHandler)
+	child := New(WithCanonicalRedirect())
+	child.Add("/users", testHandler)
+	child.Add("/a%20b", testHandler)
+	child.Mount("/v1", grandchild)
+	fold := New(WithMatchMode(MatchFoldCase | MatchRedirect))
+	fold.Add("/users", testHandler)
+	h := New()
+	h.Mount("/t/:tenant/app", child)
+	h.Mount("/t/:tenant/fold", fold)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		h.ServeHTTP(w, req)
+		resp := w.Result()
+		resp.Body.Close()
+		assertStatus(t, resp, http.StatusMovedPermanently)
+		assertHeader(t, resp, "Location", tt.location)
+		e := h.Explain(http.MethodGet, tt.path)
+		assertString(t, "explain location", e.Location, tt.location)
+	}
+}
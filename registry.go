@@ -0,0 +1,53 @@
+package mux
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// registry holds the handler constructors registered by name.
+var registry = struct {
+	sync.Mutex
+	handlers map[string]func() *Handler
+}{handlers: make(map[string]func() *Handler)}
+
+// RegisterHandler registers the handler constructor by name for
+// inspection by the muxroutes command. Call RegisterHandler from an
+// init function in a file constrained by the muxroutes build tag so
+// the registration is excluded from regular builds. RegisterHandler
+// panics if the name is already registered.
+func RegisterHandler(name string, fn func() *Handler) {
+	registry.Lock()
+	defer registry.Unlock()
+	_, ok := registry.handlers[name]
+	if ok {
+		panic(fmt.Errorf("mux: duplicate registered handler '%s'", name))
+	}
+	registry.handlers[name] = fn
+}
+
+// RegisteredHandlers returns the names of the registered handlers sorted
+// alphabetically.
+func RegisteredHandlers() []string {
+	registry.Lock()
+	defer registry.Unlock()
+	names := make([]string, 0, len(registry.handlers))
+	for name := range registry.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisteredHandler returns a new handler from the constructor
+// registered by name or nil if the name is not registered.
+func RegisteredHandler(name string) *Handler {
+	registry.Lock()
+	fn, ok := registry.handlers[name]
+	registry.Unlock()
+	if !ok {
+		return nil
+	}
+	return fn()
+}
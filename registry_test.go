@@ -0,0 +1,30 @@
+package mux
+
+import "testing"
+
+func TestRegisterHandler(t *testing.T) {
+	defer func() {
+		registry.Lock()
+		delete(registry.handlers, "test")
+		registry.Unlock()
+	}()
+	RegisterHandler("test", func() *Handler {
+		h := New()
+		h.Add("/", testHandler, WithName("index"))
+		return h
+	})
+	assertDeepEqual(t, "names", RegisteredHandlers(), []string{"test"})
+	h := RegisteredHandler("test")
+	if h == nil {
+		t.Fatalf("handler should be registered")
+	}
+	if RegisteredHandler("missing") != nil {
+		t.Fatalf("handler should not be registered")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("should panic on duplicate registration")
+		}
+	}()
+	RegisterHandler("test", func() *Handler { return New() })
+}
@@ -0,0 +1,112 @@
+package mux
+
+import "net/http"
+
+// responseWriter is a http.ResponseWriter that records the
+// status code and the number of bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	code  int
+	bytes int64
+}
+
+// wrapResponseWriter returns w wrapped to record the response. The
+// returned http.ResponseWriter implements the http.Flusher, http.Hijacker
+// and http.Pusher interfaces if and only if w implements them.
+func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWriter) {
+	rw := &responseWriter{ResponseWriter: w}
+	_, flush := w.(http.Flusher)
+	hijacker, hijack := w.(http.Hijacker)
+	pusher, push := w.(http.Pusher)
+	f := flusher{rw}
+	switch {
+	case flush && hijack && push:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{rw, f, hijacker, pusher}, rw
+	case flush && hijack:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+		}{rw, f, hijacker}, rw
+	case flush && push:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Pusher
+		}{rw, f, pusher}, rw
+	case hijack && push:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.Pusher
+		}{rw, hijacker, pusher}, rw
+	case flush:
+		return struct {
+			*responseWriter
+			http.Flusher
+		}{rw, f}, rw
+	case hijack:
+		return struct {
+			*responseWriter
+			http.Hijacker
+		}{rw, hijacker}, rw
+	case push:
+		return struct {
+			*responseWriter
+			http.Pusher
+		}{rw, pusher}, rw
+	}
+	return rw, rw
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+// Informational status codes other than 101 Switching Protocols
+// are not recorded.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.code == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// status returns the recorded status code. A response without
+// a status code written is sent with a 200 OK by net/http.
+func (w *responseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// flusher implements the http.Flusher interface for a responseWriter.
+type flusher struct {
+	w *responseWriter
+}
+
+// Flush implements the http.Flusher interface.
+func (f flusher) Flush() {
+	if f.w.code == 0 {
+		f.w.code = http.StatusOK
+	}
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
@@ -5,15 +5,21 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Route represents a route.
 type Route struct {
 	name       string
+	host       string
 	pattern    string
 	methods    map[string]struct{}
 	handler    http.Handler
 	middleware []func(http.Handler) http.Handler
+	meta       map[interface{}]interface{}
+	predicates []predicate
+	timeout    time.Duration
+	mount      *Handler
 }
 
 // NewRoute returns a new route.
@@ -38,6 +44,11 @@ func (r *Route) Name() string {
 	return r.name
 }
 
+// Host returns the route host pattern.
+func (r *Route) Host() string {
+	return r.host
+}
+
 // Pattern returns the route pattern.
 func (r *Route) Pattern() string {
 	return r.pattern
@@ -53,6 +64,21 @@ func (r *Route) Methods() []string {
 	return methods
 }
 
+// Meta returns the route metadata value for the key
+// or nil if the key is not set.
+func (r *Route) Meta(key interface{}) interface{} {
+	return r.meta[key]
+}
+
+// Metadata returns a copy of the route metadata.
+func (r *Route) Metadata() map[interface{}]interface{} {
+	meta := make(map[interface{}]interface{}, len(r.meta))
+	for k, v := range r.meta {
+		meta[k] = v
+	}
+	return meta
+}
+
 // ServeHTTP implements the http.Handler interface.
 func (r *Route) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.handler.ServeHTTP(w, req)
@@ -80,11 +106,29 @@ type Builder interface {
 	Build(name string, params Params) (string, error)
 }
 
+// Mutator represents the ability to remove and replace named routes
+// while the router is serving requests.
+type Mutator interface {
+	Remove(name string) error
+	Replace(r *Route) error
+}
+
+// Validator represents the ability to report routing problems.
+type Validator interface {
+	Validate() []Diagnostic
+}
+
 // Walker represents the ability to walk the available routes.
 type Walker interface {
 	Walk(fn WalkFunc) error
 }
 
+// AllWalker represents the ability to walk all registered routes,
+// including unnamed routes.
+type AllWalker interface {
+	WalkAll(fn WalkFunc) error
+}
+
 // WalkFunc is called for each route visited.
 // Return a non-nil error to terminate iteration.
 type WalkFunc func(r *Route) error
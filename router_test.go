@@ -52,3 +52,37 @@ func TestRouteMiddleware(t *testing.T) {
 	r.ServeHTTP(w, (*http.Request)(nil))
 	assertString(t, "middleware", have, "acdb")
 }
+
+type testMetaKey int
+
+func TestRouteMeta(t *testing.T) {
+	r := NewRoute("/", nil, WithMeta(testMetaKey(0), "admin"), WithMeta("tier", 2))
+	assertDeepEqual(t, "meta", r.Meta(testMetaKey(0)), "admin")
+	assertDeepEqual(t, "meta", r.Meta(testMetaKey(1)), nil)
+	meta := r.Metadata()
+	assertDeepEqual(t, "metadata", meta, map[interface{}]interface{}{testMetaKey(0): "admin", "tier": 2})
+	meta["tier"] = 3
+	assertDeepEqual(t, "meta", r.Meta("tier"), 2)
+}
+
+func TestRouteMetaMiddleware(t *testing.T) {
+	h := New()
+	h.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			scope, _ := Match(req).Meta(testMetaKey(0)).(string)
+			if scope != "admin" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+	h.Add("/admin", testHandler, WithMeta(testMetaKey(0), "admin"))
+	h.Add("/", testHandler)
+	for path, want := range map[string]int{"/admin": http.StatusOK, "/": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		h.ServeHTTP(w, req)
+		assertInt(t, "status code", w.Code, want)
+	}
+}
@@ -0,0 +1,221 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo represents the description of a route.
+type RouteInfo struct {
+	Methods    []string          `json:"methods"`
+	Host       string            `json:"host,omitempty"`
+	Pattern    string            `json:"pattern"`
+	Name       string            `json:"name,omitempty"`
+	Middleware int               `json:"middleware"`
+	Meta       map[string]string `json:"meta,omitempty"`
+}
+
+// newRouteInfo returns the description of the route. Metadata keys
+// that are not strings or fmt.Stringers are described by their type
+// and value.
+func newRouteInfo(r *Route) RouteInfo {
+	info := RouteInfo{
+		Methods:    r.Methods(),
+		Host:       r.Host(),
+		Pattern:    r.Pattern(),
+		Name:       r.Name(),
+		Middleware: len(r.middleware),
+	}
+	if len(r.meta) > 0 {
+		info.Meta = make(map[string]string, len(r.meta))
+		for k, v := range r.meta {
+			var key string
+			switch k := k.(type) {
+			case string:
+				key = k
+			case fmt.Stringer:
+				key = k.String()
+			default:
+				key = fmt.Sprintf("%T(%v)", k, k)
+			}
+			info.Meta[key] = fmt.Sprintf("%v", v)
+		}
+	}
+	return info
+}
+
+// RouteTable represents the descriptions of the routes of a handler.
+// RouteTable is encoded as a JSON array.
+type RouteTable []RouteInfo
+
+// String returns the route table as aligned text.
+// Routes without methods respond to any method.
+func (t RouteTable) String() string {
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHODS\tPATTERN\tNAME\tMIDDLEWARE\tMETA")
+	for _, info := range t {
+		methods := strings.Join(info.Methods, ",")
+		if methods == "" {
+			methods = "*"
+		}
+		keys := make([]string, 0, len(info.Meta))
+		for k := range info.Meta {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		meta := make([]string, len(keys))
+		for i, k := range keys {
+			meta[i] = k + "=" + info.Meta[k]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", methods, info.Host+info.Pattern, info.Name, info.Middleware, strings.Join(meta, " "))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// Routes returns the descriptions of every registered route sorted by
+// pattern if the Router is an AllWalker. See Handler.WalkAll
+// documentation for more details.
+func (h *Handler) Routes() (RouteTable, error) {
+	t := make(RouteTable, 0)
+	err := h.WalkAll(func(r *Route) error {
+		t = append(t, newRouteInfo(r))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Explanation represents the result of matching a request.
+type Explanation struct {
+	Method   string     `json:"method"`
+	URL      string     `json:"url"`
+	Route    *RouteInfo `json:"route,omitempty"`
+	Params   Params     `json:"params,omitempty"`
+	Status   int        `json:"status,omitempty"`
+	Allow    []string   `json:"allow,omitempty"`
+	Location string     `json:"location,omitempty"`
+	Reason   string     `json:"reason"`
+}
+
+// String returns the explanation as text.
+func (e Explanation) String() string {
+	s := e.Method + " " + e.URL + ": " + e.Reason
+	if e.Route == nil {
+		return s
+	}
+	keys := make([]string, 0, len(e.Params))
+	for k := range e.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s += "\n  " + k + " = " + strconv.Quote(e.Params[k])
+	}
+	return s
+}
+
+// Explain reports the route matching the method and URL and the
+// extracted params, or why the request would not match a route.
+// The URL may be a path or an absolute URL to match host patterns.
+// Routes of mounted handlers are matched in place of the mount route.
+// The route handler and middleware are not called. An invalid method
+// or URL is explained as a 400 Bad Request.
+func (h *Handler) Explain(method, url string) Explanation {
+	e := Explanation{Method: method, URL: url}
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		e.Status = http.StatusBadRequest
+		e.Reason = "invalid request: " + err.Error()
+		return e
+	}
+	h.explain(req, &e, "", "")
+	return e
+}
+
+// explain sets the explanation of matching the request. The prefix and
+// name of the mount route are applied to the route description.
+func (h *Handler) explain(req *http.Request, e *Explanation, prefix, name string) {
+	r, params, err := h.router.Match(req)
+	if err != nil {
+		merr, ok := err.(ErrMethodNotAllowed)
+		if ok && req.Method == http.MethodOptions {
+			e.Status = http.StatusOK
+			e.Allow = merr
+			e.Reason = "responds with the allowed methods of the path"
+			return
+		}
+		if err == ErrNotFound && h.redirect {
+			err = h.canonical(req)
+		}
+		switch err := err.(type) {
+		case ErrMethodNotAllowed:
+			e.Status = http.StatusMethodNotAllowed
+			e.Allow = err
+			e.Reason = "method not allowed; the path matches routes for " + err.Error()
+		case ErrRedirect:
+			e.Status = err.Code
+			e.Location = err.URL
+			e.Reason = "redirects to the canonical URL " + err.URL
+		default:
+			switch err {
+			case ErrNotFound:
+				e.Status = http.StatusNotFound
+				e.Reason = "no route matched the path"
+			case ErrDecodeContentType:
+				e.Status = http.StatusUnsupportedMediaType
+				e.Reason = "the path matches routes that do not consume the content type"
+			case ErrEncodeMatch:
+				e.Status = http.StatusNotAcceptable
+				e.Reason = "the path matches routes that do not produce an acceptable media type"
+			default:
+				e.Status = http.StatusInternalServerError
+				e.Reason = err.Error()
+			}
+		}
+		return
+	}
+	defer h.release(params)
+	if e.Params == nil {
+		e.Params = make(Params)
+	}
+	for k, v := range params {
+		e.Params[k] = unescape(v)
+	}
+	if r.mount != nil {
+		delete(e.Params, "*")
+		u := *req.URL
+		u.RawPath = "/" + params["*"]
+		u.Path = unescape(u.RawPath)
+		c := new(http.Request)
+		*c = *req
+		c.URL = &u
+		n := name
+		if r.name != "" {
+			n = joinName(name, r.name)
+		}
+		r.mount.explain(c, e, prefix+strings.TrimSuffix(r.pattern, "/*"), n)
+		if e.Location != "" {
+			e.Location = strings.TrimSuffix(req.URL.EscapedPath(), u.RawPath) + e.Location
+			e.Reason = "redirects to the canonical URL " + e.Location
+		}
+		return
+	}
+	info := newRouteInfo(r)
+	info.Pattern = prefix + info.Pattern
+	if name != "" && info.Name != "" {
+		info.Name = joinName(name, info.Name)
+	}
+	e.Route = &info
+	e.Reason = "matched route " + info.Host + info.Pattern
+	if info.Name != "" {
+		e.Reason += " named " + info.Name
+	}
+}
@@ -0,0 +1,87 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	h := New()
+	h.Use(func(next http.Handler) http.Handler { return next })
+	h.Add("/users/:id", testHandler, WithMethod(http.MethodGet), WithName("user"), WithMeta("scope", "admin"))
+	h.Add("/", testHandler)
+	routes, err := h.Routes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "METHODS   PATTERN     NAME  MIDDLEWARE  META\n" +
+		"*         /                 1           \n" +
+		"GET,HEAD  /users/:id  user  1           scope=admin\n"
+	assertString(t, "text", routes.String(), want)
+	b, err := json.Marshal(routes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = `[{"methods":[],"pattern":"/","middleware":1},` +
+		`{"methods":["GET","HEAD"],"pattern":"/users/:id","name":"user","middleware":1,"meta":{"scope":"admin"}}]`
+	assertString(t, "json", string(b), want)
+}
+
+func TestRoutesMetaKeys(t *testing.T) {
+	h := New()
+	h.Add("/users", testHandler, WithMethod(http.MethodPost),
+		WithResponse(http.StatusOK, "ok"), WithResponse(http.StatusCreated, "created"))
+	routes, err := h.Routes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "METHODS  PATTERN  NAME  MIDDLEWARE  META\n" +
+		"POST     /users         0           mux.responseKey(200)=ok mux.responseKey(201)=created\n"
+	assertString(t, "text", routes.String(), want)
+	b, err := json.Marshal(routes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = `[{"methods":["POST"],"pattern":"/users","middleware":0,` +
+		`"meta":{"mux.responseKey(200)":"ok","mux.responseKey(201)":"created"}}]`
+	assertString(t, "json", string(b), want)
+}
+
+func TestExplain(t *testing.T) {
+	child := New()
+	child.Add("/invoices/:id", testHandler, WithMethod(http.MethodGet), WithName("invoice"))
+	h := New(WithCanonicalRedirect())
+	h.Add("/users/:id", testHandler, WithMethod(http.MethodGet), WithName("user"))
+	h.Mount("/t/:tenant/billing", child, WithName("billing"))
+	var tests = []struct {
+		method string
+		url    string
+		status int
+		name   string
+		params Params
+	}{
+		{http.MethodGet, "/users/a%20b", 0, "user", Params{"id": "a b"}},
+		{http.MethodGet, "/t/acme/billing/invoices/1", 0, "billing.invoice", Params{"tenant": "acme", "id": "1"}},
+		{http.MethodDelete, "/users/1", http.StatusMethodNotAllowed, "", nil},
+		{http.MethodGet, "/users/1/", http.StatusMovedPermanently, "", nil},
+		{http.MethodGet, "/missing", http.StatusNotFound, "", nil},
+		{"BAD METHOD", "/users/1", http.StatusBadRequest, "", nil},
+		{http.MethodGet, "/users/%zz", http.StatusBadRequest, "", nil},
+	}
+	for _, tt := range tests {
+		e := h.Explain(tt.method, tt.url)
+		assertInt(t, "status", e.Status, tt.status)
+		if tt.name == "" {
+			if e.Route != nil {
+				t.Fatalf("unexpected route: %v", e.Route)
+			}
+			continue
+		}
+		if e.Route == nil {
+			t.Fatalf("missing route for '%s'\n%s", tt.url, e)
+		}
+		assertString(t, "name", e.Route.Name, tt.name)
+		assertDeepEqual(t, "params", e.Params, tt.params)
+	}
+}
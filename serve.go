@@ -0,0 +1,160 @@
+package mux
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// serveConfig represents the configuration of Serve.
+type serveConfig struct {
+	server  *http.Server
+	delay   time.Duration
+	drain   time.Duration
+	signals []os.Signal
+}
+
+// ServeOption represents a functional option for Serve.
+type ServeOption func(*serveConfig)
+
+// WithServerTimeouts sets the read, write and idle timeouts of the server.
+// The defaults are 30 seconds, 60 seconds and 120 seconds respectively.
+// The write timeout should exceed the route timeouts.
+func WithServerTimeouts(read, write, idle time.Duration) ServeOption {
+	return func(c *serveConfig) {
+		c.server.ReadTimeout = read
+		c.server.WriteTimeout = write
+		c.server.IdleTimeout = idle
+	}
+}
+
+// WithShutdownDelay sets the duration to continue serving requests after
+// shutdown is requested and before the listener is closed. Health checks
+// fail during the delay so that load balancers stop routing requests to
+// the server. The default is no delay.
+func WithShutdownDelay(d time.Duration) ServeOption {
+	return func(c *serveConfig) {
+		c.delay = d
+	}
+}
+
+// WithDrainTimeout sets the deadline for in-flight requests to complete
+// and for the shutdown hooks to run. The default is 30 seconds.
+func WithDrainTimeout(d time.Duration) ServeOption {
+	return func(c *serveConfig) {
+		c.drain = d
+	}
+}
+
+// WithSignals sets the signals requesting shutdown.
+// The defaults are SIGINT and SIGTERM.
+func WithSignals(signals ...os.Signal) ServeOption {
+	return func(c *serveConfig) {
+		c.signals = signals
+	}
+}
+
+// Serve listens on the TCP network address and serves requests until
+// the context is done or a shutdown signal is received.
+// See Handler.ServeListener documentation for more details.
+func (h *Handler) Serve(ctx context.Context, addr string, opts ...ServeOption) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return h.ServeListener(ctx, l, opts...)
+}
+
+// ServeListener serves requests on the listener until the context is done
+// or a shutdown signal is received. On shutdown, HealthCheck handlers fail
+// and in-flight requests are drained before the shutdown hooks registered
+// with OnShutdown are run in reverse order of registration. The signals
+// are released once shutdown begins, so a second signal terminates the
+// process. The nil error is returned if the server shut down cleanly.
+func (h *Handler) ServeListener(ctx context.Context, l net.Listener, opts ...ServeOption) error {
+	c := &serveConfig{
+		server: &http.Server{
+			Handler:           h,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+		},
+		drain:   30 * time.Second,
+		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+	for _, option := range opts {
+		option(c)
+	}
+	ctx, stop := signal.NotifyContext(ctx, c.signals...)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- c.server.Serve(l)
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		// Restore the default signal behavior so that
+		// a second signal terminates the process.
+		stop()
+		atomic.StoreInt32(&h.draining, 1)
+		defer atomic.StoreInt32(&h.draining, 0)
+		if c.delay > 0 {
+			time.Sleep(c.delay)
+		}
+		sctx, cancel := context.WithTimeout(context.Background(), c.drain)
+		defer cancel()
+		err = c.server.Shutdown(sctx)
+		if err == nil {
+			err = <-errs
+		}
+	}
+	if err == http.ErrServerClosed {
+		err = nil
+	}
+	hctx, cancel := context.WithTimeout(context.Background(), c.drain)
+	defer cancel()
+	herr := h.shutdown(hctx)
+	if err == nil {
+		err = herr
+	}
+	return err
+}
+
+// OnShutdown registers a hook to run when Serve shuts down, such as
+// closing database connections or flushing metrics. Hooks are run in
+// reverse order of registration after in-flight requests are drained.
+func (h *Handler) OnShutdown(fn func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, fn)
+}
+
+// Draining reports whether the handler is draining requests to shut down.
+func (h *Handler) Draining() bool {
+	return atomic.LoadInt32(&h.draining) != 0
+}
+
+// shutdown runs the shutdown hooks in reverse order of registration.
+// All hooks are run and the first error is returned.
+func (h *Handler) shutdown(ctx context.Context) error {
+	h.mu.RLock()
+	hooks := make([]func(ctx context.Context) error, len(h.hooks))
+	copy(hooks, h.hooks)
+	h.mu.RUnlock()
+	var rv error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		err := hooks[i](ctx)
+		if err != nil && rv == nil {
+			rv = err
+		}
+	}
+	return rv
+}
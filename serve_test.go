@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeListener(t *testing.T) {
+	h := New()
+	h.Handle("/health", HealthCheck(nil), WithMethod(http.MethodGet))
+	var order []int
+	for i := 0; i < 3; i++ {
+		i := i
+		h.OnShutdown(func(ctx context.Context) error {
+			order = append(order, i)
+			if i == 1 {
+				return errors.New("test")
+			}
+			return nil
+		})
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	url := "http://" + l.Addr().String() + "/health"
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- h.ServeListener(ctx, l, WithShutdownDelay(200*time.Millisecond), WithDrainTimeout(time.Second))
+	}()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	assertStatus(t, resp, http.StatusOK)
+	cancel()
+	for !h.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	assertStatus(t, resp, http.StatusServiceUnavailable)
+	err = <-errs
+	if err == nil || err.Error() != "test" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDeepEqual(t, "hooks", order, []int{2, 1, 0})
+	if h.Draining() {
+		t.Fatalf("handler should not be draining after shutdown")
+	}
+}
+
+func TestServeListenerDrain(t *testing.T) {
+	h := New()
+	started := make(chan struct{})
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- h.ServeListener(ctx, l)
+	}()
+	codes := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String() + "/")
+		if err != nil {
+			codes <- 0
+			return
+		}
+		resp.Body.Close()
+		codes <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+	err = <-errs
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertInt(t, "status code", <-codes, http.StatusNoContent)
+}
@@ -0,0 +1,198 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// StreamTrailer is the trailer set to the status code and text of the
+// error view if a stream fails after the response is committed.
+const StreamTrailer = "X-Stream-Error"
+
+// StreamEncoder represents the ability to encode
+// HTTP responses incrementally.
+type StreamEncoder interface {
+	Encoder
+	// EncodeItem encodes an item of the stream.
+	EncodeItem(w io.Writer, view Viewable) error
+	// EncodeError encodes the terminal error record of
+	// a stream that failed after the response was committed.
+	EncodeError(w io.Writer, view Error) error
+}
+
+// Iterator represents the items of a stream. Return the error
+// io.EOF to end the stream. Iterators should return when the
+// context is done.
+type Iterator func(ctx context.Context) (Viewable, error)
+
+// Stream encodes the items of the iterator and responds to the request.
+// Each item is written and flushed as it is encoded if the negotiated
+// Encoder is a StreamEncoder. Otherwise, the items are encoded as a slice
+// of the item type with Encode.
+//
+// The response is committed once the first item is iterated, so an error
+// returned by the first iteration is returned as with Encode. Later errors
+// are resolved to an error view that is encoded as the terminal error
+// record of the stream and the StreamTrailer trailer is set. The stream
+// ends without an error record if the client disconnects.
+func (h *Handler) Stream(w http.ResponseWriter, req *http.Request, iter Iterator) error {
+	e, err := h.encoder(req)
+	if err != nil {
+		return err
+	}
+	ctx := req.Context()
+	se, ok := e.(StreamEncoder)
+	if !ok {
+		views := make([]Viewable, 0)
+		for {
+			view, err := iter(ctx)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			views = append(views, view)
+		}
+		fn := func(req *http.Request) (Encoder, error) {
+			return e, nil
+		}
+		return h.encode(fn, w, req, typedViews(views), http.StatusOK)
+	}
+	view, err := iter(ctx)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	headers := w.Header()
+	addVary(headers, "Accept")
+	for k, vs := range se.Headers() {
+		for _, v := range vs {
+			headers.Add(k, v)
+		}
+	}
+	headers.Add("Trailer", StreamTrailer)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	for err == nil {
+		err = se.EncodeItem(w, view)
+		if err != nil {
+			break
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		err = ctx.Err()
+		if err != nil {
+			break
+		}
+		view, err = iter(ctx)
+	}
+	if err != io.EOF {
+		h.abortStream(w, req, se, err)
+	}
+	return nil
+}
+
+// abortStream resolves an error to a view and encodes
+// the terminal error record of a committed stream.
+func (h *Handler) abortStream(w http.ResponseWriter, req *http.Request, se StreamEncoder, err error) {
+	setError(req, err)
+	defer requestObserver(h, req).Abort(req)
+	if errors.Is(req.Context().Err(), context.Canceled) {
+		// The client disconnected.
+		return
+	}
+	view := h.resolveError(w, req, err)
+	code := view.StatusCode()
+	w.Header().Set(StreamTrailer, fmt.Sprintf("%d %s", code, http.StatusText(code)))
+	err = se.EncodeError(w, view)
+	if err != nil {
+		h.log(req, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if ok {
+		flusher.Flush()
+	}
+}
+
+// NewEventStreamEncoder returns a StreamEncoder of the text/event-stream
+// media type for server-sent events. Each item is encoded as an event with
+// JSON data. The terminal error record is encoded as an error event.
+// Each element of slice and array views is encoded as an event.
+func NewEventStreamEncoder() StreamEncoder {
+	return &eventStreamEncoder{}
+}
+
+type eventStreamEncoder struct{}
+
+func (e *eventStreamEncoder) Encode(w io.Writer, view Viewable) error {
+	v := reflect.Indirect(reflect.ValueOf(view))
+	if !isList(v) {
+		return e.EncodeItem(w, view)
+	}
+	for i := 0; i < v.Len(); i++ {
+		err := e.EncodeItem(w, v.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*eventStreamEncoder) EncodeItem(w io.Writer, view Viewable) error {
+	return encodeEvent(w, "", view)
+}
+
+func (*eventStreamEncoder) EncodeError(w io.Writer, view Error) error {
+	return encodeEvent(w, "error", view)
+}
+
+func (*eventStreamEncoder) Headers() http.Header {
+	return http.Header{
+		"Content-Type":  []string{"text/event-stream"},
+		"Cache-Control": []string{"no-cache"},
+	}
+}
+
+// encodeEvent writes a server-sent event with the JSON data of the view.
+func encodeEvent(w io.Writer, event string, view Viewable) error {
+	b, err := json.Marshal(view)
+	if err != nil {
+		return err
+	}
+	if event != "" {
+		_, err = fmt.Fprintf(w, "event: %s\n", event)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", b)
+	return err
+}
+
+// typedViews returns views as a slice of the element type shared by
+// the views so that encoders can inspect it. The views are returned
+// unchanged if they are empty or of mixed types.
+func typedViews(views []Viewable) Viewable {
+	if len(views) == 0 {
+		return views
+	}
+	typ := reflect.TypeOf(views[0])
+	if typ == nil {
+		return views
+	}
+	s := reflect.MakeSlice(reflect.SliceOf(typ), 0, len(views))
+	for _, view := range views {
+		if reflect.TypeOf(view) != typ {
+			return views
+		}
+		s = reflect.Append(s, reflect.ValueOf(view))
+	}
+	return s.Interface()
+}
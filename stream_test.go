@@ -0,0 +1,141 @@
+package mux
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	_ StreamEncoder = &eventStreamEncoder{}
+	_ StreamEncoder = &ndjsonEncoder{}
+)
+
+// testIterator returns an Iterator of n items followed by err.
+func testIterator(n int, err error) Iterator {
+	i := 0
+	return func(ctx context.Context) (Viewable, error) {
+		if i == n {
+			return nil, err
+		}
+		i++
+		return testData{N: i}, nil
+	}
+}
+
+func TestStream(t *testing.T) {
+	encoder := NewAcceptEncoder(map[string]Encoder{
+		"application/json":     NewJSONEncoder(),
+		"application/x-ndjson": NewNDJSONEncoder(),
+		"text/event-stream":    NewEventStreamEncoder(),
+		"text/csv":             NewCSVEncoder(),
+		"application/xml":      NewXMLEncoder(),
+	})
+	var tests = []struct {
+		accept  string
+		n       int
+		err     error
+		status  int
+		body    string
+		trailer string
+	}{
+		{"application/x-ndjson", 2, io.EOF, http.StatusOK, "{\"n\":1}\n{\"n\":2}\n", ""},
+		{"application/x-ndjson", 0, io.EOF, http.StatusOK, "", ""},
+		{"application/x-ndjson", 1, errors.New("test"), http.StatusOK, "{\"n\":1}\n{\"error\":{\"code\":500,\"title\":\"Internal Server Error\",\"message\":\"An unexpected error has occurred.\",\"request_id\":\"test\"}}\n", "500 Internal Server Error"},
+		{"application/x-ndjson", 0, errors.New("test"), http.StatusInternalServerError, "{\"code\":500,\"title\":\"Internal Server Error\",\"message\":\"An unexpected error has occurred.\",\"request_id\":\"test\"}\n", ""},
+		{"text/event-stream", 2, ErrNotFound, http.StatusOK, "data: {\"n\":1}\n\ndata: {\"n\":2}\n\nevent: error\ndata: {\"code\":404,\"title\":\"Not Found\",\"request_id\":\"test\"}\n\n", "404 Not Found"},
+		{"application/json", 2, io.EOF, http.StatusOK, "[{\"n\":1},{\"n\":2}]\n", ""},
+		{"application/json", 0, io.EOF, http.StatusOK, "[]\n", ""},
+		{"text/csv", 2, io.EOF, http.StatusOK, "N\n1\n2\n", ""},
+		{"application/xml", 2, io.EOF, http.StatusOK, xml.Header + "<list><testData><N>1</N></testData><testData><N>2</N></testData></list>", ""},
+		{"application/json", 1, errors.New("test"), http.StatusInternalServerError, "{\"code\":500,\"title\":\"Internal Server Error\",\"message\":\"An unexpected error has occurred.\",\"request_id\":\"test\"}\n", ""},
+	}
+	for _, tt := range tests {
+		for _, d := range []time.Duration{0, time.Hour} {
+			h := New(WithEncoder(encoder), WithLogger(testLogger))
+			h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+				return h.Stream(w, req, testIterator(tt.n, tt.err))
+			}, WithTimeout(d))
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			req.Header.Set("X-Request-ID", "test")
+			h.ServeHTTP(w, req)
+			resp := w.Result()
+			assertStatus(t, resp, tt.status)
+			assertHeader(t, resp, "Vary", "Accept")
+			assertString(t, tt.accept+" body", w.Body.String(), tt.body)
+			assertString(t, tt.accept+" trailer", resp.Trailer.Get(StreamTrailer), tt.trailer)
+		}
+	}
+}
+
+func TestStreamFlush(t *testing.T) {
+	for _, d := range []time.Duration{0, time.Hour} {
+		h := New(WithEncoder(NewAcceptEncoder(map[string]Encoder{
+			"application/x-ndjson": NewNDJSONEncoder(),
+		})))
+		w := httptest.NewRecorder()
+		req := newTestRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/x-ndjson")
+		n, flushed := 0, 0
+		h.Add("/", func(rw http.ResponseWriter, req *http.Request) error {
+			return h.Stream(rw, req, func(ctx context.Context) (Viewable, error) {
+				if w.Flushed {
+					flushed++
+					w.Flushed = false
+				}
+				n++
+				if flushed == 2 || n > 2 {
+					return nil, io.EOF
+				}
+				return testData{N: 1}, nil
+			})
+		}, WithTimeout(d))
+		h.ServeHTTP(w, req)
+		assertInt(t, "flushed", flushed, 2)
+		assertString(t, "body", w.Body.String(), "{\"n\":1}\n{\"n\":1}\n")
+	}
+}
+
+func TestStreamDisconnect(t *testing.T) {
+	observer := &testResponseObserver{}
+	h := New(WithObserver(observer), WithEncoder(NewAcceptEncoder(map[string]Encoder{
+		"application/x-ndjson": NewNDJSONEncoder(),
+	})))
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		return h.Stream(w, req, func(ctx context.Context) (Viewable, error) {
+			n++
+			if n == 2 {
+				cancel()
+			}
+			return testData{N: n}, nil
+		})
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	req.Header.Set("Accept", "application/x-ndjson")
+	h.ServeHTTP(w, req)
+	assertInt(t, "iterations", n, 2)
+	assertString(t, "body", w.Body.String(), "{\"n\":1}\n{\"n\":2}\n")
+	assertString(t, "trailer", w.Result().Trailer.Get(StreamTrailer), "")
+	if !errors.Is(observer.responses[0].Err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", observer.responses[0].Err)
+	}
+}
+
+func TestEventStreamEncoder(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := NewEventStreamEncoder().Encode(w, []testData{{N: 1}, {N: 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "body", w.Body.String(), "data: {\"n\":1}\n\ndata: {\"n\":2}\n\n")
+}
@@ -0,0 +1,280 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// ErrTemplateNotFound indicates that no template was found for the view.
+var ErrTemplateNotFound = errors.New("mux: template not found")
+
+// errTemplateRequest indicates that a template func was called
+// while encoding without a request.
+var errTemplateRequest = errors.New("mux: template func requires a request")
+
+// RequestEncoder represents an Encoder that encodes
+// views with the incoming HTTP request. Handler.Encode
+// calls EncodeRequest instead of Encode if implemented.
+type RequestEncoder interface {
+	Encoder
+	EncodeRequest(w io.Writer, req *http.Request, view Viewable) error
+}
+
+// Templater represents a view that names its template.
+type Templater interface {
+	Template() string
+}
+
+// TemplateEncoder represents an Encoder that encodes views with
+// html/template templates loaded from a file system.
+type TemplateEncoder struct {
+	fsys      fs.FS
+	ext       string
+	layout    string
+	partials  []string
+	funcs     template.FuncMap
+	reload    bool
+	templates map[string]*template.Template
+}
+
+// TemplateOption represents a functional option for configuration.
+type TemplateOption func(*TemplateEncoder)
+
+// WithTemplateExt sets the file name extension of page templates.
+// Defaults to ".html".
+func WithTemplateExt(ext string) TemplateOption {
+	return func(e *TemplateEncoder) {
+		e.ext = ext
+	}
+}
+
+// WithTemplateLayout sets the path of the layout template. The layout
+// is executed in place of each page template, and is expected to include
+// the templates defined by the page, such as with {{block "content" .}}.
+func WithTemplateLayout(name string) TemplateOption {
+	return func(e *TemplateEncoder) {
+		e.layout = name
+	}
+}
+
+// WithTemplatePartials sets the glob patterns of partial templates
+// available to every page template.
+func WithTemplatePartials(patterns ...string) TemplateOption {
+	return func(e *TemplateEncoder) {
+		e.partials = append(e.partials, patterns...)
+	}
+}
+
+// WithTemplateFuncs adds the funcs to the template func map.
+func WithTemplateFuncs(funcs template.FuncMap) TemplateOption {
+	return func(e *TemplateEncoder) {
+		for k, v := range funcs {
+			e.funcs[k] = v
+		}
+	}
+}
+
+// WithTemplateReload parses the templates each time a view is encoded
+// so that changes are visible without restarting. Intended for development.
+func WithTemplateReload() TemplateOption {
+	return func(e *TemplateEncoder) {
+		e.reload = true
+	}
+}
+
+// NewTemplateEncoder returns a new TemplateEncoder with the page
+// templates of the file system. The layout and partial templates
+// are not page templates. Page templates are named by their path
+// without the extension, such as users/show for users/show.html.
+//
+// The template of a view is chosen by the Template method of views
+// implementing Templater, then by the type name of the view, such
+// as ErrorView, and then by the name of the matched route. Error
+// views are not chosen by the route name.
+//
+// The build, locale and requestID funcs return the URL of a named route
+// with parameters as key and value pairs, the request locale and the
+// request identifier, respectively.
+func NewTemplateEncoder(fsys fs.FS, opts ...TemplateOption) (*TemplateEncoder, error) {
+	e := &TemplateEncoder{
+		fsys:  fsys,
+		ext:   ".html",
+		funcs: templateFuncs(nil),
+	}
+	for _, option := range opts {
+		option(e)
+	}
+	if e.reload {
+		return e, nil
+	}
+	templates, err := e.parse()
+	if err != nil {
+		return nil, err
+	}
+	e.templates = templates
+	return e, nil
+}
+
+// Encode implements the Encoder interface.
+func (e *TemplateEncoder) Encode(w io.Writer, view Viewable) error {
+	return e.encode(w, nil, view)
+}
+
+// EncodeRequest implements the RequestEncoder interface.
+func (e *TemplateEncoder) EncodeRequest(w io.Writer, req *http.Request, view Viewable) error {
+	return e.encode(w, req, view)
+}
+
+// Headers implements the Encoder interface.
+func (e *TemplateEncoder) Headers() http.Header {
+	return http.Header{"Content-Type": []string{"text/html; charset=utf-8"}}
+}
+
+func (e *TemplateEncoder) encode(w io.Writer, req *http.Request, view Viewable) error {
+	templates := e.templates
+	if e.reload {
+		var err error
+		templates, err = e.parse()
+		if err != nil {
+			return err
+		}
+	}
+	var t *template.Template
+	for _, name := range templateNames(req, view) {
+		t = templates[name]
+		if t != nil {
+			break
+		}
+	}
+	if t == nil {
+		return ErrTemplateNotFound
+	}
+	// Executed templates cannot be cloned, so the parsed templates
+	// are only cloned and each clone is executed with the request funcs.
+	t, err := t.Clone()
+	if err != nil {
+		return err
+	}
+	t.Funcs(templateFuncs(req))
+	name := t.Name()
+	if e.layout != "" {
+		name = path.Base(e.layout)
+	}
+	return t.ExecuteTemplate(w, name, view)
+}
+
+// parse returns the page templates of the file system by name.
+func (e *TemplateEncoder) parse() (map[string]*template.Template, error) {
+	shared := e.partials
+	if e.layout != "" {
+		shared = append([]string{e.layout}, shared...)
+	}
+	templates := make(map[string]*template.Template)
+	err := fs.WalkDir(e.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != e.ext || matchAny(shared, name) {
+			return err
+		}
+		b, err := fs.ReadFile(e.fsys, name)
+		if err != nil {
+			return err
+		}
+		name = strings.TrimSuffix(name, e.ext)
+		t := template.New(name).Funcs(e.funcs)
+		if len(shared) > 0 {
+			_, err = t.ParseFS(e.fsys, shared...)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = t.Parse(string(b))
+		if err != nil {
+			return err
+		}
+		templates[name] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// matchAny reports whether the name matches any of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		ok, _ := path.Match(pattern, name)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// templateNames returns the candidate template names of the view.
+func templateNames(req *http.Request, view Viewable) []string {
+	var names []string
+	v, ok := view.(Templater)
+	if ok {
+		names = append(names, v.Template())
+	}
+	t := reflect.TypeOf(view)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && t.Name() != "" {
+		names = append(names, t.Name())
+	}
+	_, isError := view.(error)
+	if req != nil && !isError {
+		r := Match(req)
+		if r != nil && r.Name() != "" {
+			names = append(names, r.Name())
+		}
+	}
+	return names
+}
+
+// templateFuncs returns the template funcs of the request.
+// The funcs return an error if the request is nil.
+func templateFuncs(req *http.Request) template.FuncMap {
+	var rc *requestContext
+	if req != nil {
+		rc, _ = req.Context().Value(requestContextKey).(*requestContext)
+	}
+	return template.FuncMap{
+		"build": func(name string, pairs ...string) (string, error) {
+			if rc == nil || rc.root == nil {
+				return "", errTemplateRequest
+			}
+			if len(pairs)%2 != 0 {
+				return "", fmt.Errorf("mux: build %s: odd number of parameters", name)
+			}
+			params := make(Params, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				params[pairs[i]] = pairs[i+1]
+			}
+			return rc.root.Build(name, params)
+		},
+		"locale": func() (language.Tag, error) {
+			if rc == nil {
+				return language.Und, errTemplateRequest
+			}
+			return rc.locale, nil
+		},
+		"requestID": func() (string, error) {
+			if rc == nil {
+				return "", errTemplateRequest
+			}
+			return RequestID(req), nil
+		},
+	}
+}
@@ -0,0 +1,151 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var _ RequestEncoder = &TemplateEncoder{}
+
+type testTemplateView struct {
+	Name string
+}
+
+func (v testTemplateView) Template() string {
+	return "pages/custom"
+}
+
+func newTestTemplateFS() fstest.MapFS {
+	file := func(s string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(s), ModTime: time.Now()}
+	}
+	return fstest.MapFS{
+		"layout.html":        file(`<title>{{block "title" .}}Test{{end}}</title>{{template "content" .}}`),
+		"partials/nav.html":  file(`{{define "nav"}}<a href="{{build "pages/user" "id" "1"}}">{{requestID}}</a>{{end}}`),
+		"pages/user.html":    file(`{{define "title"}}User{{end}}{{define "content"}}{{template "nav"}}<p>{{.N}} {{locale}}</p>{{end}}`),
+		"pages/custom.html":  file(`{{define "content"}}<p>{{.Name}}</p>{{end}}`),
+		"ErrorView.html":     file(`{{define "content"}}<p>{{.Code}} {{.Title}}</p>{{end}}`),
+		"pages/notes.txt":    file(`ignored`),
+		"pages/unused.html":  file(`{{define "content"}}unused{{end}}`),
+		"pages/invalid.tmpl": file(`{{`),
+	}
+}
+
+func TestTemplateEncoder(t *testing.T) {
+	e, err := NewTemplateEncoder(newTestTemplateFS(), WithTemplateLayout("layout.html"), WithTemplatePartials("partials/*.html"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := New(WithEncoder(NewAcceptEncoder(map[string]Encoder{
+		"text/html":        e,
+		"application/json": &jsonEncoder{},
+	})))
+	h.Add("/users/:id", func(w http.ResponseWriter, req *http.Request) error {
+		return h.Encode(w, req, testData{N: 1}, http.StatusOK)
+	}, WithName("pages/user"))
+	h.Add("/custom", func(w http.ResponseWriter, req *http.Request) error {
+		return h.Encode(w, req, testTemplateView{Name: "<custom>"}, http.StatusOK)
+	}, WithName("pages/unused"))
+	h.Add("/missing", func(w http.ResponseWriter, req *http.Request) error {
+		return h.Encode(w, req, struct{}{}, http.StatusOK)
+	})
+	var tests = []struct {
+		path   string
+		accept string
+		status int
+		body   string
+	}{
+		{"/users/1", "text/html", http.StatusOK, `<title>User</title><a href="/users/1">test</a><p>1 en</p>`},
+		{"/users/1", "application/json", http.StatusOK, `{"n":1}` + "\n"},
+		{"/custom", "text/html", http.StatusOK, `<title>Test</title><p>&lt;custom&gt;</p>`},
+		{"/404", "text/html", http.StatusNotFound, `<title>Test</title><p>404 Not Found</p>`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		req.Header.Set("X-Request-ID", "test")
+		h.ServeHTTP(w, req)
+		resp := w.Result()
+		assertStatus(t, resp, tt.status)
+		assertString(t, tt.path, w.Body.String(), tt.body)
+	}
+	req := newTestRequest(http.MethodGet, "/missing", nil)
+	err = e.EncodeRequest(httptest.NewRecorder(), req, struct{}{})
+	if err != ErrTemplateNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTemplateEncoderWithoutRequest(t *testing.T) {
+	e, err := NewTemplateEncoder(newTestTemplateFS(), WithTemplateLayout("layout.html"), WithTemplatePartials("partials/*.html"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var b strings.Builder
+	err = e.Encode(&b, testTemplateView{Name: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "body", b.String(), `<title>Test</title><p>test</p>`)
+	err = e.Encode(&b, testData{N: 1})
+	if err != ErrTemplateNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = e.EncodeRequest(&b, httptest.NewRequest(http.MethodGet, "/", nil), ErrorView{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fsys := newTestTemplateFS()
+	fsys["testData.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}{{locale}}{{end}}`)}
+	e, err = NewTemplateEncoder(fsys, WithTemplateLayout("layout.html"), WithTemplatePartials("partials/*.html"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = e.Encode(&b, testData{N: 1})
+	if !errors.Is(err, errTemplateRequest) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTemplateEncoderReload(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(`{{.Name}}`)},
+	}
+	e, err := NewTemplateEncoder(fsys, WithTemplateReload(), WithTemplateFuncs(map[string]interface{}{
+		"upper": strings.ToUpper,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var tests = []string{`{{.Name}}`, `{{upper .Name}}`}
+	var want = []string{"test", "TEST"}
+	for i, tt := range tests {
+		fsys["index.html"] = &fstest.MapFile{Data: []byte(tt)}
+		var b strings.Builder
+		err = e.Encode(&b, templateView{"index", "test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertString(t, "body", b.String(), want[i])
+	}
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`{{`)}
+	err = e.Encode(&strings.Builder{}, templateView{"index", "test"})
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+type templateView struct {
+	name string
+	Name string
+}
+
+func (v templateView) Template() string {
+	return v.name
+}
@@ -0,0 +1,283 @@
+package mux
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTimeout represents a HTTP 503 Service Unavailable error
+// resolved when the request deadline passes before the response
+// is committed.
+var ErrTimeout = errors.New("mux: request timed out")
+
+// routeTimeout returns the request timeout of the route.
+func (h *Handler) routeTimeout(r *Route) time.Duration {
+	if r.timeout > 0 {
+		return r.timeout
+	}
+	return h.timeout
+}
+
+// serveTimeout dispatches the request to the route with a deadline.
+// If the deadline passes before the response is committed, the response
+// is resolved from ErrTimeout and writes by the route are discarded.
+// The route handler is expected to return once the request context is
+// done. If the response was already committed, the route is awaited.
+// serveTimeout reports whether the route returned.
+func (h *Handler) serveTimeout(w http.ResponseWriter, req *http.Request, r *Route, d time.Duration) bool {
+	ctx, cancel := context.WithTimeout(req.Context(), d)
+	defer cancel()
+	req = req.WithContext(ctx)
+	tw := &timeoutWriter{w: w, header: make(http.Header)}
+	rw := wrapTimeoutWriter(tw)
+	done := make(chan struct{})
+	panics := make(chan Panic, 1)
+	go func() {
+		defer func() {
+			err := recover()
+			if err != nil {
+				panics <- Panic{err: err, stack: debug.Stack()}
+				return
+			}
+			close(done)
+		}()
+		r.ServeHTTP(rw, req)
+	}()
+	select {
+	case <-done:
+	case p := <-panics:
+		h.Abort(tw, req, p)
+	case <-ctx.Done():
+		if tw.timeout() {
+			h.Abort(w, req, ErrTimeout)
+			return false
+		}
+		select {
+		case <-done:
+		case p := <-panics:
+			h.Abort(tw, req, p)
+		}
+	}
+	tw.writeTrailers()
+	return true
+}
+
+// timeoutWriter is a http.ResponseWriter that discards writes
+// after the request times out. Headers are buffered until the
+// response is committed. Trailers set after the response is
+// committed are copied once the route returns.
+type timeoutWriter struct {
+	w         http.ResponseWriter
+	mu        sync.Mutex
+	header    http.Header
+	committed bool
+	timedOut  bool
+}
+
+// Header implements the http.ResponseWriter interface.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write implements the http.ResponseWriter interface.
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.committed {
+		tw.writeHeader(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.committed {
+		return
+	}
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	dst := tw.w.Header()
+	for k, vs := range tw.header {
+		dst[k] = append([]string(nil), vs...)
+	}
+	tw.committed = true
+	tw.w.WriteHeader(code)
+}
+
+// writeTrailers copies the trailers of a committed response
+// to the underlying http.ResponseWriter. Trailers are the keys
+// declared in the Trailer header or prefixed with http.TrailerPrefix.
+func (tw *timeoutWriter) writeTrailers() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || !tw.committed {
+		return
+	}
+	dst := tw.w.Header()
+	for _, v := range tw.header["Trailer"] {
+		for _, k := range strings.Split(v, ",") {
+			k = http.CanonicalHeaderKey(strings.TrimSpace(k))
+			vs, ok := tw.header[k]
+			if ok {
+				dst[k] = append([]string(nil), vs...)
+			}
+		}
+	}
+	for k, vs := range tw.header {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			dst[k] = append([]string(nil), vs...)
+		}
+	}
+}
+
+// timeout marks the writer as timed out and reports whether
+// the response was not yet committed.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.committed {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+// expired reports whether the request timed out.
+func (tw *timeoutWriter) expired() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.timedOut
+}
+
+// isTimedOut reports whether w is a timed out timeoutWriter.
+func isTimedOut(w http.ResponseWriter) bool {
+	tw, ok := w.(interface{ expired() bool })
+	return ok && tw.expired()
+}
+
+// wrapTimeoutWriter returns tw wrapped to implement the http.Flusher,
+// http.Hijacker and http.Pusher interfaces if and only if the
+// underlying http.ResponseWriter implements them.
+func wrapTimeoutWriter(tw *timeoutWriter) http.ResponseWriter {
+	_, flush := tw.w.(http.Flusher)
+	_, hijack := tw.w.(http.Hijacker)
+	_, push := tw.w.(http.Pusher)
+	f := timeoutFlusher{tw}
+	j := timeoutHijacker{tw}
+	p := timeoutPusher{tw}
+	switch {
+	case flush && hijack && push:
+		return struct {
+			*timeoutWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{tw, f, j, p}
+	case flush && hijack:
+		return struct {
+			*timeoutWriter
+			http.Flusher
+			http.Hijacker
+		}{tw, f, j}
+	case flush && push:
+		return struct {
+			*timeoutWriter
+			http.Flusher
+			http.Pusher
+		}{tw, f, p}
+	case hijack && push:
+		return struct {
+			*timeoutWriter
+			http.Hijacker
+			http.Pusher
+		}{tw, j, p}
+	case flush:
+		return struct {
+			*timeoutWriter
+			http.Flusher
+		}{tw, f}
+	case hijack:
+		return struct {
+			*timeoutWriter
+			http.Hijacker
+		}{tw, j}
+	case push:
+		return struct {
+			*timeoutWriter
+			http.Pusher
+		}{tw, p}
+	}
+	return tw
+}
+
+// timeoutFlusher implements the http.Flusher interface for a timeoutWriter.
+type timeoutFlusher struct {
+	tw *timeoutWriter
+}
+
+// Flush implements the http.Flusher interface. Flushing
+// commits the response. Flushes after the request times
+// out are discarded.
+func (f timeoutFlusher) Flush() {
+	tw := f.tw
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if !tw.committed {
+		tw.writeHeader(http.StatusOK)
+	}
+	tw.w.(http.Flusher).Flush()
+}
+
+// timeoutHijacker implements the http.Hijacker interface for a timeoutWriter.
+type timeoutHijacker struct {
+	tw *timeoutWriter
+}
+
+// Hijack implements the http.Hijacker interface. A hijacked
+// connection is treated as a committed response.
+func (j timeoutHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw := j.tw
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	conn, rw, err := tw.w.(http.Hijacker).Hijack()
+	if err == nil {
+		tw.committed = true
+	}
+	return conn, rw, err
+}
+
+// timeoutPusher implements the http.Pusher interface for a timeoutWriter.
+type timeoutPusher struct {
+	tw *timeoutWriter
+}
+
+// Push implements the http.Pusher interface.
+func (p timeoutPusher) Push(target string, opts *http.PushOptions) error {
+	tw := p.tw
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return http.ErrHandlerTimeout
+	}
+	return tw.w.(http.Pusher).Push(target, opts)
+}
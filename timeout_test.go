@@ -0,0 +1,165 @@
+package mux
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testAbortObserver struct {
+	discardObserver
+	aborts int32
+}
+
+func (o *testAbortObserver) Abort(req *http.Request) {
+	atomic.AddInt32(&o.aborts, 1)
+}
+
+func TestTimeout(t *testing.T) {
+	observer := &testAbortObserver{}
+	h := New(WithObserver(observer), WithDefaultTimeout(time.Hour))
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		defer close(returned)
+		w.Header().Set("X-Partial", "1")
+		<-req.Context().Done()
+		<-release
+		_, err := io.WriteString(w, "late")
+		return err
+	}, WithTimeout(10*time.Millisecond))
+	w := httptest.NewRecorder()
+	req := newTestRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, req)
+	close(release)
+	<-returned
+	assertInt(t, "status code", w.Code, http.StatusServiceUnavailable)
+	assertString(t, "header", w.Header().Get("X-Partial"), "")
+	var view ErrorView
+	err := json.NewDecoder(w.Body).Decode(&view)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertInt(t, "code", view.Code, http.StatusServiceUnavailable)
+	assertInt(t, "aborts", int(atomic.LoadInt32(&observer.aborts)), 1)
+}
+
+func TestTimeoutCommitted(t *testing.T) {
+	h := New()
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		<-req.Context().Done()
+		_, err := io.WriteString(w, "done")
+		return err
+	}, WithTimeout(10*time.Millisecond))
+	w := httptest.NewRecorder()
+	req := newTestRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusAccepted)
+	assertString(t, "body", w.Body.String(), "done")
+}
+
+func TestTimeoutDeadlineExceeded(t *testing.T) {
+	h := New()
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(time.Hour))
+	w := httptest.NewRecorder()
+	req := newTestRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusGatewayTimeout)
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	h := New(WithLogger(testLogger))
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		panic("test")
+	}, WithTimeout(time.Hour))
+	w := httptest.NewRecorder()
+	req := newTestRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, req)
+	assertInt(t, "status code", w.Code, http.StatusInternalServerError)
+}
+
+func TestTimeoutInterfaces(t *testing.T) {
+	var tests = []struct {
+		w             http.ResponseWriter
+		flush, hijack bool
+	}{
+		{httptest.NewRecorder(), true, false},
+		{testHijacker{httptest.NewRecorder()}, true, true},
+		{struct{ http.ResponseWriter }{httptest.NewRecorder()}, false, false},
+	}
+	for _, tt := range tests {
+		h := New()
+		h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+			_, flush := w.(http.Flusher)
+			_, hijack := w.(http.Hijacker)
+			_, push := w.(http.Pusher)
+			if flush != tt.flush || hijack != tt.hijack || push {
+				t.Fatalf("interfaces\nhave %v %v %v\nwant %v %v false", flush, hijack, push, tt.flush, tt.hijack)
+			}
+			return nil
+		}, WithTimeout(time.Hour))
+		req := newTestRequest(http.MethodGet, "/", nil)
+		h.ServeHTTP(tt.w, req)
+	}
+}
+
+func TestTimeoutStream(t *testing.T) {
+	h := New(WithDefaultTimeout(time.Hour))
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		w.Header().Set("Trailer", "X-Checksum")
+		_, err := io.WriteString(w, "a")
+		if err != nil {
+			return err
+		}
+		w.(http.Flusher).Flush()
+		w.Header().Set("X-Checksum", "1")
+		w.Header().Set(http.TrailerPrefix+"X-Count", "1")
+		w.Header().Set("X-Late", "1")
+		return nil
+	})
+	w := httptest.NewRecorder()
+	req := newTestRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, req)
+	if !w.Flushed {
+		t.Fatal("response not flushed")
+	}
+	resp := w.Result()
+	assertString(t, "body", w.Body.String(), "a")
+	assertString(t, "trailer", resp.Trailer.Get("X-Checksum"), "1")
+	assertString(t, "prefixed trailer", resp.Trailer.Get("X-Count"), "1")
+	assertString(t, "header", resp.Header.Get("X-Late"), "")
+}
+
+func TestTimeoutFlushTimedOut(t *testing.T) {
+	h := New()
+	returned := make(chan struct{})
+	h.Add("/", func(w http.ResponseWriter, req *http.Request) error {
+		defer close(returned)
+		<-req.Context().Done()
+		for !isTimedOut(w) {
+			time.Sleep(time.Millisecond)
+		}
+		w.(http.Flusher).Flush()
+		_, _, err := w.(http.Hijacker).Hijack()
+		return err
+	}, WithTimeout(10*time.Millisecond))
+	w := httptest.NewRecorder()
+	req := newTestRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(testHijacker{w}, req)
+	<-returned
+	assertInt(t, "status code", w.Code, http.StatusServiceUnavailable)
+	if w.Flushed {
+		t.Fatal("timed out response flushed")
+	}
+}
@@ -1,97 +1,315 @@
 package mux
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // empty represents the empty method set.
 const empty = "EMPTY"
 
+// MatchMode represents the path matching mode of the default router.
+type MatchMode int
+
+// Match modes.
+const (
+	// MatchFoldCase matches static route segments case-insensitively.
+	// Params are matched with the original request text.
+	MatchFoldCase MatchMode = 1 << iota
+
+	// MatchNormalize matches each segment of the escaped request path
+	// unescaped and normalized to Unicode Normalization Form C. Escaped
+	// slashes do not separate segments. Route patterns are also
+	// normalized. Params are the original escaped request text.
+	MatchNormalize
+
+	// MatchRedirect redirects requests to the canonical path of the
+	// matched route if the request path differs, such as by casing
+	// with MatchFoldCase or by normalization with MatchNormalize.
+	MatchRedirect
+)
+
 // tree is the default router implementation.
-// tree also implements the Builder and Walker interfaces.
+// tree also implements the Builder, Mutator and Walker interfaces.
+//
+// Routes are read from an immutable snapshot with an atomic load.
+// Mutations build a new snapshot under lock, which is published
+// atomically. Routes added before the first read are batched in
+// a pending snapshot, which is published by the first reader.
 type tree struct {
-	names   map[string]*Route
-	methods map[string]*node
+	mode    MatchMode
+	mu      sync.Mutex
+	pending *snapshot
+	current atomic.Value
+}
+
+// snapshot represents the routes of a tree at a point in time.
+type snapshot struct {
+	mode   MatchMode
+	routes []*Route
+	names  map[string]*Route
+	hosts  []*host
+}
+
+// newSnapshot returns a new snapshot of the routes.
+func newSnapshot(mode MatchMode, routes []*Route) (*snapshot, error) {
+	s := &snapshot{mode: mode, routes: make([]*Route, 0, len(routes))}
+	for _, r := range routes {
+		err := s.add(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
 }
 
 // Add adds r to the tree.
 func (t *tree) Add(r *Route) error {
+	return t.edit(func(s *snapshot) error {
+		return s.add(r)
+	})
+}
+
+// Remove removes the named route from the tree.
+//
+// Remove implements the Mutator interface.
+func (t *tree) Remove(name string) error {
+	return t.edit(func(s *snapshot) error {
+		r, ok := s.names[name]
+		if !ok {
+			return fmt.Errorf("mux: named route '%s' does not exist", name)
+		}
+		routes := make([]*Route, 0, len(s.routes))
+		for _, route := range s.routes {
+			if route != r {
+				routes = append(routes, route)
+			}
+		}
+		return s.rebuild(routes)
+	})
+}
+
+// Replace replaces the route with the same name as r.
+//
+// Replace implements the Mutator interface.
+func (t *tree) Replace(r *Route) error {
+	name := r.Name()
+	if name == "" {
+		return errors.New("mux: replacement route must be named")
+	}
+	return t.edit(func(s *snapshot) error {
+		old, ok := s.names[name]
+		if !ok {
+			return fmt.Errorf("mux: named route '%s' does not exist", name)
+		}
+		routes := make([]*Route, len(s.routes))
+		for i, route := range s.routes {
+			if route == old {
+				route = r
+			}
+			routes[i] = route
+		}
+		return s.rebuild(routes)
+	})
+}
+
+// edit applies fn to a new snapshot under lock and publishes it.
+// Before the first read, fn is applied to the pending snapshot.
+func (t *tree) edit(fn func(s *snapshot) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, ok := t.current.Load().(*snapshot)
+	if !ok {
+		if t.pending == nil {
+			t.pending = &snapshot{mode: t.mode}
+		}
+		return fn(t.pending)
+	}
+	s, err := newSnapshot(t.mode, current.routes)
+	if err != nil {
+		return err
+	}
+	err = fn(s)
+	if err != nil {
+		return err
+	}
+	t.current.Store(s)
+	return nil
+}
+
+// load returns the current snapshot. The first reader
+// publishes the routes added during construction.
+func (t *tree) load() *snapshot {
+	s, ok := t.current.Load().(*snapshot)
+	if ok {
+		return s
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok = t.current.Load().(*snapshot)
+	if ok {
+		return s
+	}
+	s = t.pending
+	if s == nil {
+		s = &snapshot{mode: t.mode}
+	}
+	t.pending = nil
+	t.current.Store(s)
+	return s
+}
+
+// add adds r to the snapshot. The snapshot is rebuilt without r if
+// it could not be added.
+func (s *snapshot) add(r *Route) error {
 	name := r.Name()
 	if name != "" {
-		if t.names == nil {
-			t.names = make(map[string]*Route)
+		if s.names == nil {
+			s.names = make(map[string]*Route)
 		}
-		_, ok := t.names[name]
+		_, ok := s.names[name]
 		if ok {
 			return fmt.Errorf("mux: duplicate named route '%s'", name)
 		}
-		t.names[name] = r
 	}
-	methods := r.Methods()
-	if len(methods) == 0 {
-		methods = append(methods, empty)
+	h := s.host(r.Host())
+	if h == nil {
+		var err error
+		h, err = s.addHost(r.Host())
+		if err != nil {
+			return err
+		}
 	}
-	if t.methods == nil {
-		t.methods = make(map[string]*node)
+	pattern := r.Pattern()
+	if s.mode&MatchNormalize != 0 {
+		pattern = norm.NFC.String(pattern)
 	}
-	for _, method := range methods {
-		_, ok := t.methods[method]
-		if !ok {
-			t.methods[method] = &node{}
+	err := h.add(r, pattern)
+	if err != nil {
+		// Discard the partially added route.
+		rerr := s.rebuild(s.routes)
+		if rerr != nil {
+			return rerr
 		}
-		err := t.methods[method].add(r)
-		if err != nil {
-			return err
+		return err
+	}
+	s.routes = append(s.routes, r)
+	if name != "" {
+		s.names[name] = r
+	}
+	return nil
+}
+
+// rebuild replaces the snapshot with a new snapshot of the routes.
+func (s *snapshot) rebuild(routes []*Route) error {
+	rv, err := newSnapshot(s.mode, routes)
+	if err != nil {
+		return err
+	}
+	*s = *rv
+	return nil
+}
+
+// host returns the host for the pattern or nil if it does not exist.
+func (s *snapshot) host(pattern string) *host {
+	for _, h := range s.hosts {
+		if h.pattern == pattern {
+			return h
 		}
 	}
 	return nil
 }
 
+// addHost adds a new host for the pattern.
+// Hosts are ordered by the number of host parameters and then by
+// registration, with the any host pattern last.
+func (s *snapshot) addHost(pattern string) (*host, error) {
+	h, err := newHost(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.hosts = append(s.hosts, h)
+	sort.SliceStable(s.hosts, func(i, j int) bool {
+		return s.hosts[i].rank() < s.hosts[j].rank()
+	})
+	return h, nil
+}
+
 // Build returns the URL for the named route or an error if
 // the named route does not exist or a parameter is missing.
+// Optional segments are omitted if their parameters are missing.
+// A scheme-relative URL is returned if the route has a host pattern.
 //
 // Build implements the Builder interface.
 func (t *tree) Build(name string, params Params) (string, error) {
-	r, ok := t.names[name]
+	s := t.load()
+	r, ok := s.names[name]
 	if !ok {
 		return "", ErrBuild
 	}
+	var prefix string
+	if r.Host() != "" {
+		hostname, err := s.host(r.Host()).build(params)
+		if err != nil {
+			return "", err
+		}
+		prefix = "//" + hostname
+	}
+	patterns, err := expand(r.Pattern())
+	if err != nil {
+		return "", err
+	}
+	for _, pattern := range patterns {
+		path, err := build(pattern, params)
+		if err == ErrBuild {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return prefix + path, nil
+	}
+	return "", ErrBuild
+}
+
+// build returns the path for the pattern without optional segments.
+// Missing splats are treated as empty. Param values are escaped as path
+// segments. Splat values are escaped as paths.
+func build(pattern string, params Params) (string, error) {
 	var buf strings.Builder
-	pattern := r.Pattern()
 	for pattern != "" {
-		b := pattern[0]
-		switch b {
+		switch pattern[0] {
 		case ':':
-			var i int
-			for i = 1; i < len(pattern); i++ {
-				if isBreak(pattern[i]) {
-					break
-				}
-			}
-			key := pattern[1:i]
+			key, expr, i := parseParam(pattern)
 			v, ok := params[key]
 			if !ok {
 				return "", ErrBuild
 			}
-			buf.WriteString(v)
+			if expr != "" {
+				re, err := compileConstraint(expr)
+				if err != nil {
+					return "", err
+				}
+				if !re.MatchString(v) {
+					return "", fmt.Errorf("mux: param '%s' does not satisfy constraint '%s'", key, expr)
+				}
+			}
+			buf.WriteString(url.PathEscape(v))
 			pattern = pattern[i:]
 		case '*':
-			key := string(b)
-			v, ok := params[key]
-			if ok {
-				buf.WriteString(v)
-			}
-			pattern = pattern[1:]
+			key, i := parseSplat(pattern)
+			buf.WriteString(escapeSplat(params[key]))
+			pattern = pattern[i:]
 		default:
-			var i int
-			for i = 1; i < len(pattern); i++ {
-				if isParam(pattern[i]) {
-					break
-				}
-			}
+			i := nextParam(pattern)
 			buf.WriteString(pattern[:i])
 			pattern = pattern[i:]
 		}
@@ -99,56 +317,264 @@ func (t *tree) Build(name string, params Params) (string, error) {
 	return buf.String(), nil
 }
 
+// releaser represents a Router that pools the params returned by Match.
+// The Handler releases the params once the request is served.
+type releaser interface {
+	release(params Params)
+}
+
 // Match returns the matching route and parameters.
+//
+// Routes with a host pattern matching the request host are tried
+// before routes without a host pattern. Host patterns with fewer
+// host parameters are tried first.
+//
+// The params are pooled to avoid allocations. Release the params
+// with release once no longer in use, such as when the request is
+// served by a Handler.
 func (t *tree) Match(req *http.Request) (*Route, Params, error) {
-	path := req.URL.EscapedPath()
-	root, ok := t.methods[req.Method]
-	if ok {
-		route, params, err := root.match(path)
+	m := getMatch()
+	defer putMatch(m)
+	err := t.lookup(req, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m.leaf.route, m.params(), nil
+}
+
+// release returns the params to the pool.
+func (t *tree) release(params Params) {
+	putParams(params)
+}
+
+// lookup sets the matching leaf and param values for the request on m.
+func (t *tree) lookup(req *http.Request, m *match) error {
+	raw := req.URL.EscapedPath()
+	path := raw
+	var offsets []int
+	if t.mode&MatchNormalize != 0 {
+		path, offsets = normalize(raw)
+	}
+	name := hostname(req)
+	m.fold = t.mode&MatchFoldCase != 0
+	var rv error = ErrNotFound
+	for _, h := range t.load().hosts {
+		m.keys = nil
+		m.truncate(0)
+		if !h.match(name, m) {
+			continue
+		}
+		err := h.lookup(req.Method, path, m)
 		if err == nil {
-			return route, params, nil
+			err = h.choose(req, path, m)
+		}
+		if err == nil && t.mode&MatchRedirect != 0 {
+			err = t.canonical(req, m)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	root, ok = t.methods[empty]
-	if ok {
-		route, params, err := root.match(path)
 		if err == nil {
-			return route, params, nil
+			if offsets != nil {
+				denormalize(raw, path, offsets, m)
+			}
+			return nil
+		}
+		if rv == ErrNotFound {
+			rv = err
 		}
 	}
-	allowed := make([]string, 0)
-	for method := range t.methods {
-		if method == req.Method || method == http.MethodOptions {
+	return rv
+}
+
+// normalize returns the escaped path with each segment unescaped and
+// normalized to NFC. Slashes and percent signs within segments remain
+// escaped. The offsets map each byte offset of the normalized path to
+// the escaped path, or -1 within segments changed by normalization.
+func normalize(raw string) (string, []int) {
+	var buf strings.Builder
+	offsets := make([]int, 0, len(raw)+1)
+	for i := 0; i < len(raw); {
+		if raw[i] == '/' {
+			buf.WriteByte('/')
+			offsets = append(offsets, i)
+			i++
 			continue
 		}
-		root = t.methods[method]
-		_, _, err := root.match(path)
-		if err == nil {
-			allowed = append(allowed, method)
+		j := strings.IndexByte(raw[i:], '/')
+		if j < 0 {
+			j = len(raw)
+		} else {
+			j += i
+		}
+		segment := raw[i:j]
+		s := unescape(segment)
+		n := norm.NFC.String(s)
+		if n == s {
+			for k := 0; k < len(segment); {
+				c, w := decodeByte(segment[k:])
+				width := writeSegmentByte(&buf, c)
+				for e := 0; e < width; e++ {
+					if e < w {
+						offsets = append(offsets, i+k+e)
+						continue
+					}
+					offsets = append(offsets, -1)
+				}
+				k += w
+			}
+		} else {
+			start := len(offsets)
+			for k := 0; k < len(n); k++ {
+				for x := writeSegmentByte(&buf, n[k]); x > 0; x-- {
+					offsets = append(offsets, -1)
+				}
+			}
+			offsets[start] = i
 		}
+		i = j
 	}
-	if len(allowed) > 0 {
-		allowed = append(allowed, http.MethodOptions)
-		sort.Strings(allowed)
-		return nil, nil, ErrMethodNotAllowed(allowed)
+	return buf.String(), append(offsets, len(raw))
+}
+
+// denormalize replaces the path param values of m matched in the
+// normalized path with the text of the escaped path. Values within
+// segments changed by normalization are escaped instead.
+func denormalize(raw, path string, offsets []int, m *match) {
+	for i := len(m.keys); i < len(m.values); i++ {
+		v := m.values[i]
+		start := len(path) - m.rest[i]
+		a, b := offsets[start], offsets[start+len(v)]
+		if a >= 0 && b >= 0 {
+			m.values[i] = raw[a:b]
+			continue
+		}
+		segments := strings.Split(v, "/")
+		for j, segment := range segments {
+			segments[j] = url.PathEscape(unescape(segment))
+		}
+		m.values[i] = strings.Join(segments, "/")
+	}
+}
+
+// decodeByte returns the first byte of the escaped text
+// and the width of its encoding.
+func decodeByte(s string) (byte, int) {
+	if len(s) >= 3 && s[0] == '%' && isHex(s[1]) && isHex(s[2]) {
+		return unhex(s[1])<<4 | unhex(s[2]), 3
+	}
+	return s[0], 1
+}
+
+// writeSegmentByte writes the byte of a normalized path segment,
+// escaping slashes and percent signs, and returns the width written.
+func writeSegmentByte(buf *strings.Builder, c byte) int {
+	switch c {
+	case '/':
+		buf.WriteString("%2F")
+		return 3
+	case '%':
+		buf.WriteString("%25")
+		return 3
+	}
+	buf.WriteByte(c)
+	return 1
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
+
+// canonical returns a redirect to the canonical path of the matched
+// route if the request path differs.
+func (t *tree) canonical(req *http.Request, m *match) error {
+	params := m.params()
+	defer putParams(params)
+	for k, v := range params {
+		params[k] = unescape(v)
 	}
-	return nil, nil, ErrNotFound
+	path, err := build(m.leaf.pattern, params)
+	if err != nil {
+		return err
+	}
+	if unescape(path) == req.URL.Path {
+		return nil
+	}
+	if req.URL.RawQuery != "" {
+		path += "?" + req.URL.RawQuery
+	}
+	return ErrRedirect{URL: path, Code: redirectCode(req.Method)}
+}
+
+// escapeSplat escapes the path segments of the splat value.
+func escapeSplat(v string) string {
+	return strings.ReplaceAll(url.PathEscape(v), "%2F", "/")
+}
+
+// unescape returns the unescaped path or v if it is not escaped properly.
+func unescape(v string) string {
+	s, err := url.PathUnescape(v)
+	if err != nil {
+		return v
+	}
+	return s
 }
 
 // Walk yields named routes to fn sorting alphabetically.
 //
 // Walk implements the Walker interface.
 func (t *tree) Walk(fn WalkFunc) error {
+	s := t.load()
 	names := make([]string, 0)
-	for name := range t.names {
+	for name := range s.names {
 		names = append(names, name)
 	}
 	sort.Strings(names)
 	for _, name := range names {
-		err := fn(t.names[name])
+		err := fn(s.names[name])
 		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// WalkAll yields every route to fn once sorting by pattern.
+// Routes of the same pattern are yielded by host pattern and then
+// in order of registration.
+//
+// WalkAll implements the AllWalker interface.
+func (t *tree) WalkAll(fn WalkFunc) error {
+	s := t.load()
+	routes := make([]*Route, len(s.routes))
+	copy(routes, s.routes)
+	sortRoutes(routes)
+	for _, r := range routes {
+		err := fn(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortRoutes sorts the routes by pattern and then by host pattern.
+func sortRoutes(routes []*Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if a.pattern != b.pattern {
+			return a.pattern < b.pattern
+		}
+		return a.host < b.host
+	})
+}
@@ -1,16 +1,22 @@
 package mux
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var (
-	_ Router  = &tree{}
-	_ Builder = &tree{}
-	_ Walker  = &tree{}
+	_ Router    = &tree{}
+	_ Builder   = &tree{}
+	_ Mutator   = &tree{}
+	_ Walker    = &tree{}
+	_ AllWalker = &tree{}
 )
 
 func TestTreeAddDuplicateName(t *testing.T) {
@@ -53,6 +59,9 @@ func TestTreeBuild(t *testing.T) {
 		{"wildcard", "/files/*", Params{"*": "path/to/file.txt"}, "/files/path/to/file.txt"},
 		{"param+param", "/:a/:b", Params{"a": "a", "b": "b"}, "/a/b"},
 		{"param+wildcard", "/:a/:b/*", Params{"a": "a", "b": "b", "*": "c/d"}, "/a/b/c/d"},
+		{"named+wildcard", "/repo/*path/blob/:file", Params{"path": "a/b", "file": "c"}, "/repo/a/b/blob/c"},
+		{"optional", "/docs(/:version(/:page))", Params{"version": "v1"}, "/docs/v1"},
+		{"optional+empty", "/help(/:version)", nil, "/help"},
 	}
 	tree := &tree{}
 	for _, tt := range tests {
@@ -86,6 +95,45 @@ func TestTreeBuildNilWildcard(t *testing.T) {
 	}
 }
 
+func TestTreeBuildConstraint(t *testing.T) {
+	tree := &tree{}
+	r := NewRoute("/users/:id{int}", nil, WithName("user"))
+	err := tree.Add(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	have, err := tree.Build("user", Params{"id": "42"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "build", have, "/users/42")
+	_, err = tree.Build("user", Params{"id": "abc"})
+	if err == nil {
+		t.Fatalf("should not build params that violate the constraint")
+	}
+}
+
+func TestTreeBuildEscape(t *testing.T) {
+	tree := &tree{}
+	for _, pattern := range []string{"/posts/:slug", "/files/*"} {
+		r := NewRoute(pattern, nil, WithName(pattern))
+		err := tree.Add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	have, err := tree.Build("/posts/:slug", Params{"slug": "../a/b c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "build", have, "/posts/..%2Fa%2Fb%20c")
+	have, err = tree.Build("/files/*", Params{"*": "caf\u00e9/a b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "build", have, "/files/caf%C3%A9/a%20b")
+}
+
 func TestTreeMatch(t *testing.T) {
 	var tests = []struct {
 		pattern string
@@ -144,6 +192,148 @@ func TestTreeMatchNotFound(t *testing.T) {
 	}
 }
 
+func TestTreeMatchHost(t *testing.T) {
+	var tests = []struct {
+		host    string
+		path    string
+		pattern string
+		params  Params
+	}{
+		{"acme.example.com", "/", "{tenant}.example.com/", Params{"tenant": "acme"}},
+		{"ACME.Example.com:8080", "/users/1", "{tenant}.example.com/users/:id", Params{"tenant": "acme", "id": "1"}},
+		{"api.example.com", "/", "api.example.com/", nil},
+		{"example.com", "/", "/", nil},
+		{"example.com", "/users/1", "", nil},
+	}
+	tree := &tree{}
+	for _, pattern := range []string{"/", "{tenant}.example.com/", "{tenant}.example.com/users/:id", "api.example.com/"} {
+		var host string
+		i := strings.IndexByte(pattern, '/')
+		host, pattern = pattern[:i], pattern[i:]
+		r := NewRoute(pattern, nil, WithHost(host))
+		err := tree.Add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		r, params, err := tree.Match(req)
+		if tt.pattern == "" {
+			if err != ErrNotFound {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n for '%s%s'", err, tt.host, tt.path)
+		}
+		assertString(t, "route", r.Host()+r.Pattern(), tt.pattern)
+		assertDeepEqual(t, "params", params, tt.params)
+	}
+}
+
+func TestTreeBuildHost(t *testing.T) {
+	tree := &tree{}
+	r := NewRoute("/users/:id", nil, WithHost("{tenant}.example.com"), WithName("user"))
+	err := tree.Add(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	have, err := tree.Build("user", Params{"tenant": "acme", "id": "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "build", have, "//acme.example.com/users/1")
+	_, err = tree.Build("user", Params{"id": "1"})
+	if err != ErrBuild {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTreeMatchFoldCase(t *testing.T) {
+	tree := &tree{mode: MatchFoldCase}
+	r := NewRoute("/about-us/:name", nil)
+	err := tree.Add(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/About-Us/Carl", nil)
+	_, params, err := tree.Match(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDeepEqual(t, "params", params, Params{"name": "Carl"})
+}
+
+func TestTreeMatchNormalize(t *testing.T) {
+	tree := &tree{mode: MatchNormalize}
+	for _, pattern := range []string{"/caf\u00e9/:name", "/caf\u00e9/:name.:ext", "/files/*path"} {
+		err := tree.Add(NewRoute(pattern, nil))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var tests = []struct {
+		path   string
+		params Params
+	}{
+		{"/caf%C3%A9/na%C3%AFve", Params{"name": "na%C3%AFve"}},
+		{"/cafe%CC%81/nai%CC%88ve", Params{"name": "nai%CC%88ve"}},
+		{"/cafe%CC%81/a%2Fb", Params{"name": "a%2Fb"}},
+		{"/caf%C3%A9/a%252Fb", Params{"name": "a%252Fb"}},
+		{"/caf%C3%A9/na%C3%AFve.txt", Params{"name": "na%C3%AFve", "ext": "txt"}},
+		{"/caf%C3%A9/nai%CC%88ve.txt", Params{"name": "na%C3%AFve", "ext": "txt"}},
+		{"/files/a%2Fb/nai%CC%88ve", Params{"path": "a%2Fb/nai%CC%88ve"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		_, params, err := tree.Match(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n for '%s'", err, tt.path)
+		}
+		assertDeepEqual(t, "params", params, tt.params)
+	}
+}
+
+func TestTreeMatchRedirect(t *testing.T) {
+	var tests = []struct {
+		mode     MatchMode
+		method   string
+		path     string
+		location string
+	}{
+		{MatchFoldCase | MatchRedirect, http.MethodGet, "/About-Us/Carl?a=b", "/about-us/Carl?a=b"},
+		{MatchFoldCase | MatchRedirect, http.MethodPost, "/ABOUT-US/Carl", "/about-us/Carl"},
+		{MatchFoldCase | MatchRedirect, http.MethodGet, "/about-us/Carl", ""},
+		{MatchFoldCase | MatchNormalize | MatchRedirect, http.MethodGet, "/About-Us/cafe%CC%81", "/about-us/caf%C3%A9"},
+		{MatchNormalize | MatchRedirect, http.MethodGet, "/about-us/a%2Fb", ""},
+	}
+	for _, tt := range tests {
+		tree := &tree{mode: tt.mode}
+		r := NewRoute("/about-us/:name", nil, WithMethod(http.MethodGet, http.MethodPost))
+		err := tree.Add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		_, _, err = tree.Match(req)
+		if tt.location == "" {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			continue
+		}
+		redirect, ok := err.(ErrRedirect)
+		if !ok {
+			t.Fatalf("unexpected error: %v\n for '%s'", err, tt.path)
+		}
+		assertString(t, "location", redirect.URL, tt.location)
+		assertInt(t, "code", redirect.Code, redirectCode(tt.method))
+	}
+}
+
 func TestTreeWalk(t *testing.T) {
 	patterns := []string{"/rubicon", "/ruber", "/rubicundus", "/romanus", "/romane", "/romulus", "/rubens"}
 	tree := &tree{}
@@ -169,3 +359,311 @@ func TestTreeWalk(t *testing.T) {
 		t.Fatalf("Walk\nhave %v\nwant %v", have, want)
 	}
 }
+
+func TestTreeWalkAll(t *testing.T) {
+	tree := &tree{}
+	routes := []*Route{
+		NewRoute("/b", nil, WithName("b")),
+		NewRoute("/a", nil, WithHost("example.com")),
+		NewRoute("/c", nil),
+		NewRoute("/a", nil),
+	}
+	for _, r := range routes {
+		err := tree.Add(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	have := make([]*Route, 0)
+	err := tree.WalkAll(func(r *Route) error {
+		have = append(have, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []*Route{routes[3], routes[1], routes[0], routes[2]}
+	assertDeepEqual(t, "WalkAll", have, want)
+}
+
+func TestTreeRemove(t *testing.T) {
+	tree := &tree{}
+	for _, pattern := range []string{"/a", "/a/:b", "/c"} {
+		err := tree.Add(NewRoute(pattern, nil, WithName(pattern)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	err := tree.Remove("/a/:b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/a/b", nil)
+	_, _, err = tree.Match(req)
+	if err != ErrNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = tree.Build("/a/:b", Params{"b": "b"})
+	if err != ErrBuild {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/c", nil)
+	_, _, err = tree.Match(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tree.Remove("/a/:b")
+	if err == nil {
+		t.Fatalf("should not remove missing named route")
+	}
+	err = tree.Add(NewRoute("/a/:c", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTreeReplace(t *testing.T) {
+	tree := &tree{}
+	err := tree.Add(NewRoute("/a", nil, WithName("a")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tree.Add(NewRoute("/b/:id", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tree.Replace(NewRoute("/z", nil, WithName("a")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/a", nil)
+	_, _, err = tree.Match(req)
+	if err != ErrNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/z", nil)
+	r, _, err := tree.Match(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "pattern", r.Pattern(), "/z")
+	err = tree.Replace(NewRoute("/b/:name", nil, WithName("a")))
+	if err == nil {
+		t.Fatalf("should not replace with conflicting route")
+	}
+	req = httptest.NewRequest(http.MethodGet, "/z", nil)
+	_, _, err = tree.Match(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tree.Replace(NewRoute("/y", nil))
+	if err == nil {
+		t.Fatalf("should not replace unnamed route")
+	}
+	err = tree.Replace(NewRoute("/y", nil, WithName("y")))
+	if err == nil {
+		t.Fatalf("should not replace missing named route")
+	}
+}
+
+func TestTreeConcurrentMutation(t *testing.T) {
+	tree := newBenchmarkTree(t)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/p/a/b", nil)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				_, _, err := tree.Match(req)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("r%d", i)
+		err := tree.Add(NewRoute("/r/"+name, nil, WithName(name)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = tree.Replace(NewRoute("/q/"+name, nil, WithName(name)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = tree.Build(name, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if i%2 == 0 {
+			err = tree.Remove(name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestTreeMatchLockFree(t *testing.T) {
+	tree := newBenchmarkTree(t)
+	req := httptest.NewRequest(http.MethodGet, "/p/a/b", nil)
+	_, params, err := tree.Match(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tree.release(params)
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	done := make(chan error)
+	go func() {
+		_, params, err := tree.Match(req)
+		tree.release(params)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("match blocked on the writer lock")
+	}
+}
+
+func TestTreeLookupAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not tested with the race detector")
+	}
+	tree := newBenchmarkTree(t)
+	for _, path := range []string{"/rubicundus", "/p/a/b", "/p/a/b/c"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		allocs := testing.AllocsPerRun(100, func() {
+			m := getMatch()
+			err := tree.lookup(req, m)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			putMatch(m)
+		})
+		if allocs != 0 {
+			t.Fatalf("lookup '%s'\nhave %v allocs\nwant 0 allocs", path, allocs)
+		}
+	}
+}
+
+func TestTreeMatchAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not tested with the race detector")
+	}
+	tree := newBenchmarkTree(t)
+	for _, path := range []string{"/rubicundus", "/p/a/b", "/p/a/b/c"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		allocs := testing.AllocsPerRun(100, func() {
+			_, params, err := tree.Match(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tree.release(params)
+		})
+		if allocs != 0 {
+			t.Fatalf("match '%s'\nhave %v allocs\nwant 0 allocs", path, allocs)
+		}
+	}
+}
+
+func TestTreeMatchRelease(t *testing.T) {
+	tree := newBenchmarkTree(t)
+	req := httptest.NewRequest(http.MethodGet, "/p/a/b", nil)
+	_, params, err := tree.Match(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDeepEqual(t, "params", params, Params{"a": "a", "b": "b"})
+	tree.release(params)
+	assertInt(t, "released", len(params), 0)
+	req = httptest.NewRequest(http.MethodGet, "/p/c/d/e", nil)
+	_, params, err = tree.Match(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertDeepEqual(t, "params", params, Params{"a": "c", "b": "d", "*": "e"})
+}
+
+func BenchmarkTreeLookupStatic(b *testing.B) {
+	benchmarkTreeLookup(b, "/rubicundus")
+}
+
+func BenchmarkTreeLookupParam(b *testing.B) {
+	benchmarkTreeLookup(b, "/p/a/b")
+}
+
+func BenchmarkTreeLookupSplat(b *testing.B) {
+	benchmarkTreeLookup(b, "/p/a/b/c")
+}
+
+func BenchmarkTreeMatchStatic(b *testing.B) {
+	benchmarkTreeMatch(b, "/rubicundus")
+}
+
+func BenchmarkTreeMatchParam(b *testing.B) {
+	benchmarkTreeMatch(b, "/p/a/b")
+}
+
+func BenchmarkTreeMatchSplat(b *testing.B) {
+	benchmarkTreeMatch(b, "/p/a/b/c")
+}
+
+func benchmarkTreeLookup(b *testing.B, path string) {
+	tree := newBenchmarkTree(b)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := getMatch()
+		err := tree.lookup(req, m)
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		putMatch(m)
+	}
+}
+
+func benchmarkTreeMatch(b *testing.B, path string) {
+	tree := newBenchmarkTree(b)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, params, err := tree.Match(req)
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		tree.release(params)
+	}
+}
+
+func newBenchmarkTree(tb testing.TB) *tree {
+	patterns := []string{
+		"/romane", "/romanus", "/romulus", "/rubens", "/ruber", "/rubicon", "/rubicundus",
+		"/p/:a", "/p/:a/", "/p/:a/z", "/p/:a/:b", "/p/:a/:b/*", "/",
+	}
+	tree := &tree{}
+	for _, pattern := range patterns {
+		r := NewRoute(pattern, nil, WithMethod(http.MethodGet))
+		err := tree.Add(r)
+		if err != nil {
+			tb.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return tree
+}
@@ -0,0 +1,336 @@
+package mux
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiagnosticKind represents the kind of a routing problem.
+type DiagnosticKind int
+
+// Diagnostic kinds.
+const (
+	// ParamConflict reports params of different names sharing a tree edge.
+	// The param value is stored under the name of the matched route.
+	ParamConflict DiagnosticKind = iota + 1
+
+	// Unreachable reports a route that is shadowed by another route,
+	// such as by case folding or by a route of a parent handler
+	// registered within a mount prefix.
+	Unreachable
+
+	// AmbiguousSplat reports a pattern with more than one splat.
+	AmbiguousSplat
+
+	// MethodMismatch reports a method that is registered on some but not
+	// all overlapping patterns. Requests for the method are handled by a
+	// different route than requests for the other methods of the route.
+	MethodMismatch
+)
+
+// String implements the fmt.Stringer interface.
+func (k DiagnosticKind) String() string {
+	switch k {
+	case ParamConflict:
+		return "param conflict"
+	case Unreachable:
+		return "unreachable route"
+	case AmbiguousSplat:
+		return "ambiguous splat"
+	case MethodMismatch:
+		return "method mismatch"
+	}
+	return "unknown"
+}
+
+// Diagnostic represents a routing problem found by validation.
+type Diagnostic struct {
+	Kind    DiagnosticKind
+	Route   *Route
+	Other   *Route
+	Message string
+}
+
+// String implements the fmt.Stringer interface.
+func (d Diagnostic) String() string {
+	return d.Kind.String() + ": " + d.Message
+}
+
+// samples holds the candidate param values used to match patterns.
+var samples = []string{"~", "1", "a", "00000000-0000-0000-0000-000000000000"}
+
+// Validate returns the routing problems of the tree.
+//
+// Validate implements the Validator interface.
+func (t *tree) Validate() []Diagnostic {
+	s := t.load()
+	rv := make([]Diagnostic, 0)
+	for i, r := range s.routes {
+		rv = append(rv, s.validateSplats(r)...)
+		for _, other := range s.routes[i+1:] {
+			rv = append(rv, s.validateParams(r, other)...)
+		}
+	}
+	for _, r := range s.routes {
+		rv = append(rv, s.validateRoute(r)...)
+		if r.mount != nil {
+			rv = append(rv, s.validateMount(r)...)
+		}
+	}
+	return rv
+}
+
+// validateSplats reports the patterns of r with more than one splat.
+func (s *snapshot) validateSplats(r *Route) []Diagnostic {
+	patterns, _ := expand(r.Pattern())
+	for _, pattern := range patterns {
+		n := 0
+		for _, seg := range segments(pattern) {
+			if seg.kind == '*' {
+				n++
+			}
+		}
+		if n > 1 {
+			return []Diagnostic{{
+				Kind:    AmbiguousSplat,
+				Route:   r,
+				Message: fmt.Sprintf("route '%s' has %d splats", describe(r), n),
+			}}
+		}
+	}
+	return nil
+}
+
+// validateParams reports the params of a and b that share
+// a tree edge with different names.
+func (s *snapshot) validateParams(a, b *Route) []Diagnostic {
+	if a.Host() != b.Host() || !shareMethod(a, b) {
+		return nil
+	}
+	pa, _ := expand(a.Pattern())
+	pb, _ := expand(b.Pattern())
+	for _, x := range pa {
+		for _, y := range pb {
+			m, n, ok := conflict(x, y)
+			if !ok {
+				continue
+			}
+			return []Diagnostic{{
+				Kind:    ParamConflict,
+				Route:   b,
+				Other:   a,
+				Message: fmt.Sprintf("route '%s' names param '%s' and route '%s' names it '%s'", describe(b), n, describe(a), m),
+			}}
+		}
+	}
+	return nil
+}
+
+// validateRoute reports r if its patterns match another route and the
+// methods registered on the other route but not on r.
+func (s *snapshot) validateRoute(r *Route) []Diagnostic {
+	h := s.host(r.Host())
+	patterns, _ := expand(r.Pattern())
+	methods := routeMethods(r)
+	rv := make([]Diagnostic, 0)
+	seen := make(map[*Route]bool)
+	mismatch := make(map[*Route][]string)
+	var others []*Route
+	for _, pattern := range patterns {
+		path, ok := sample(pattern)
+		if !ok {
+			continue
+		}
+		for _, method := range methods {
+			other := s.search(h, method, path, r)
+			if other == nil || other == r || seen[other] {
+				continue
+			}
+			seen[other] = true
+			rv = append(rv, Diagnostic{
+				Kind:    Unreachable,
+				Route:   r,
+				Other:   other,
+				Message: fmt.Sprintf("route '%s' is shadowed by route '%s' for %s %s", describe(r), describe(other), method, path),
+			})
+		}
+		if len(r.methods) == 0 {
+			continue
+		}
+		for method := range h.methods {
+			_, ok := r.methods[method]
+			if ok || method == empty {
+				continue
+			}
+			other := s.search(h, method, path, r)
+			if other == nil || other == r || len(other.methods) == 0 {
+				continue
+			}
+			if mismatch[other] == nil {
+				others = append(others, other)
+			}
+			if !contains(mismatch[other], method) {
+				mismatch[other] = append(mismatch[other], method)
+			}
+		}
+	}
+	for _, other := range others {
+		methods := mismatch[other]
+		sort.Strings(methods)
+		rv = append(rv, Diagnostic{
+			Kind:    MethodMismatch,
+			Route:   r,
+			Other:   other,
+			Message: fmt.Sprintf("%s requests for route '%s' are handled by route '%s'", strings.Join(methods, ", "), describe(r), describe(other)),
+		})
+	}
+	return rv
+}
+
+// validateMount reports the routes of the handler mounted by r that are
+// shadowed by other routes. The diagnostics of the mounted handler are
+// also returned.
+func (s *snapshot) validateMount(r *Route) []Diagnostic {
+	h := s.host(r.Host())
+	prefix := strings.TrimSuffix(r.Pattern(), "/*")
+	rv := make([]Diagnostic, 0)
+	_ = r.mount.WalkAll(func(c *Route) error {
+		patterns, _ := expand(prefix + c.Pattern())
+		for _, pattern := range patterns {
+			path, ok := sample(pattern)
+			if !ok {
+				continue
+			}
+			for _, method := range routeMethods(c) {
+				other := s.search(h, method, path, r)
+				if other == nil || other == r {
+					continue
+				}
+				rv = append(rv, Diagnostic{
+					Kind:    Unreachable,
+					Route:   c,
+					Other:   other,
+					Message: fmt.Sprintf("mounted route '%s' is shadowed by route '%s' for %s %s", prefix+c.Pattern(), describe(other), method, path),
+				})
+				return nil
+			}
+		}
+		return nil
+	})
+	return append(rv, r.mount.Validate()...)
+}
+
+// search returns the first route of the host matching the method and
+// path. Predicates are not tested, so r is returned if it shares the
+// pattern of the matching route.
+func (s *snapshot) search(h *host, method, path string, r *Route) *Route {
+	m := getMatch()
+	defer putMatch(m)
+	m.fold = s.mode&MatchFoldCase != 0
+	err := h.lookup(method, path, m)
+	if err != nil {
+		return nil
+	}
+	for l := m.leaf; l != nil; l = l.next {
+		if l.route == r {
+			return r
+		}
+	}
+	return m.leaf.route
+}
+
+// conflict returns the names of the first params of the patterns without
+// optional segments that share a tree edge with different names.
+func conflict(a, b string) (string, string, bool) {
+	x, y := segments(a), segments(b)
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i].kind != y[i].kind || x[i].expr != y[i].expr {
+			return "", "", false
+		}
+		if x[i].kind == 0 {
+			if x[i].text != y[i].text {
+				return "", "", false
+			}
+			continue
+		}
+		if x[i].text != y[i].text {
+			return x[i].text, y[i].text, true
+		}
+	}
+	return "", "", false
+}
+
+// sample returns a path matching the pattern without optional segments.
+// The path is not returned if a param constraint is not satisfied by any
+// of the sample values.
+func sample(pattern string) (string, bool) {
+	var buf strings.Builder
+	for _, seg := range segments(pattern) {
+		switch seg.kind {
+		case ':':
+			v, ok := sampleParam(seg.expr)
+			if !ok {
+				return "", false
+			}
+			buf.WriteString(v)
+		case '*':
+			buf.WriteString(samples[0])
+		default:
+			buf.WriteString(seg.text)
+		}
+	}
+	return buf.String(), true
+}
+
+// sampleParam returns a sample value satisfying the param constraint.
+func sampleParam(expr string) (string, bool) {
+	if expr == "" {
+		return samples[0], true
+	}
+	re, err := compileConstraint(expr)
+	if err != nil {
+		return "", false
+	}
+	for _, v := range samples {
+		if re.MatchString(v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// routeMethods returns the methods of r to match. The empty method set
+// is returned for a route without methods.
+func routeMethods(r *Route) []string {
+	methods := r.Methods()
+	if len(methods) == 0 {
+		return []string{empty}
+	}
+	return methods
+}
+
+// shareMethod reports whether routes a and b are stored in the same
+// method tree of a host.
+func shareMethod(a, b *Route) bool {
+	for _, method := range routeMethods(a) {
+		if contains(routeMethods(b), method) {
+			return true
+		}
+	}
+	return false
+}
+
+// describe returns the host and pattern of r.
+func describe(r *Route) string {
+	return r.Host() + r.Pattern()
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
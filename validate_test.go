@@ -0,0 +1,78 @@
+package mux
+
+import (
+	"net/http"
+	"testing"
+)
+
+var _ Validator = &tree{}
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		routes []*Route
+		mode   MatchMode
+		want   []DiagnosticKind
+	}{
+		{[]*Route{
+			NewRoute("/users/new", nil, WithMethod(http.MethodGet)),
+			NewRoute("/users/:id", nil, WithMethod(http.MethodGet)),
+			NewRoute("/users/:id{int}", nil, WithMethod(http.MethodGet)),
+		}, 0, nil},
+		{[]*Route{
+			NewRoute("/users/:id", nil, WithMethod(http.MethodGet)),
+			NewRoute("/users/:name/posts", nil, WithMethod(http.MethodGet)),
+		}, 0, []DiagnosticKind{ParamConflict}},
+		{[]*Route{
+			NewRoute("/users/:id", nil, WithMethod(http.MethodGet)),
+			NewRoute("/users/:name/posts", nil, WithMethod(http.MethodPost)),
+		}, 0, nil},
+		{[]*Route{
+			NewRoute("/About", nil),
+			NewRoute("/about", nil),
+		}, 0, nil},
+		{[]*Route{
+			NewRoute("/About", nil),
+			NewRoute("/about", nil),
+		}, MatchFoldCase, []DiagnosticKind{Unreachable}},
+		{[]*Route{
+			NewRoute("/files/*dir/*name", nil),
+		}, 0, []DiagnosticKind{AmbiguousSplat}},
+		{[]*Route{
+			NewRoute("/users/new", nil, WithMethod(http.MethodGet)),
+			NewRoute("/users/:id", nil, WithMethod(http.MethodGet, http.MethodDelete)),
+		}, 0, []DiagnosticKind{MethodMismatch}},
+	}
+	for _, tt := range tests {
+		tree := &tree{mode: tt.mode}
+		for _, r := range tt.routes {
+			err := tree.Add(r)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		var have []DiagnosticKind
+		for _, d := range tree.Validate() {
+			have = append(have, d.Kind)
+		}
+		assertDeepEqual(t, "diagnostics", have, tt.want)
+	}
+}
+
+func TestValidateMount(t *testing.T) {
+	child := New()
+	child.Add("/users", testHandler, WithName("users"))
+	child.Add("/posts", testHandler, WithName("posts"))
+	h := New()
+	h.Add("/api/users", testHandler)
+	h.Mount("/api", child)
+	diagnostics := h.Validate()
+	if len(diagnostics) != 1 {
+		t.Fatalf("diagnostics\nhave %v\nwant 1", diagnostics)
+	}
+	d := diagnostics[0]
+	if d.Kind != Unreachable {
+		t.Fatalf("unexpected diagnostic: %v", d)
+	}
+	assertString(t, "route", d.Route.Pattern(), "/users")
+	assertString(t, "other", d.Other.Pattern(), "/api/users")
+}
@@ -0,0 +1,60 @@
+package mux
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// NewXMLEncoder returns an Encoder of the application/xml media type.
+// The view is encoded with the encoding/xml package after the standard
+// XML header. Slice and array views are wrapped in a list root element
+// so that the document is well-formed.
+func NewXMLEncoder() Encoder {
+	return &xmlEncoder{}
+}
+
+type xmlEncoder struct{}
+
+func (*xmlEncoder) Encode(w io.Writer, view Viewable) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if !isList(reflect.Indirect(reflect.ValueOf(view))) {
+		return enc.Encode(view)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: "list"}}
+	err = enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	err = enc.Encode(view)
+	if err != nil {
+		return err
+	}
+	err = enc.EncodeToken(start.End())
+	if err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func (*xmlEncoder) Headers() http.Header {
+	return http.Header{"Content-Type": []string{"application/xml; charset=utf-8"}}
+}
+
+// NewXMLDecoder returns a Decoder of the application/xml media type.
+func NewXMLDecoder() Decoder {
+	return &xmlDecoder{}
+}
+
+type xmlDecoder struct{}
+
+// Decode implements the Decoder interface.
+func (*xmlDecoder) Decode(req *http.Request, form Form) error {
+	defer req.Body.Close()
+	return xml.NewDecoder(req.Body).Decode(form)
+}
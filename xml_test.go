@@ -0,0 +1,74 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testXMLForm struct {
+	N int `xml:"n"`
+}
+
+func (f *testXMLForm) Validate() error {
+	return nil
+}
+
+func TestXMLEncoder(t *testing.T) {
+	var b strings.Builder
+	err := NewXMLEncoder().Encode(&b, testXMLForm{N: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertString(t, "body", b.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<testXMLForm><n>1</n></testXMLForm>`)
+}
+
+func TestXMLEncoderList(t *testing.T) {
+	var tests = []struct {
+		view Viewable
+		body string
+	}{
+		{[]testXMLForm{{N: 1}, {N: 2}}, `<list><testXMLForm><n>1</n></testXMLForm><testXMLForm><n>2</n></testXMLForm></list>`},
+		{&[]testXMLForm{{N: 1}}, `<list><testXMLForm><n>1</n></testXMLForm></list>`},
+		{[]testXMLForm{}, `<list></list>`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := NewXMLEncoder().Encode(&b, tt.view)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertString(t, "body", b.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+tt.body)
+	}
+}
+
+func TestXMLDecoder(t *testing.T) {
+	h := New(WithDecoder(NewContentTypeDecoder(map[string]Decoder{
+		"application/xml": NewXMLDecoder(),
+	})))
+	req := newTestRequest(http.MethodPost, "/", strings.NewReader(`<testXMLForm><n>1</n></testXMLForm>`))
+	req.Header.Set("Content-Type", "application/xml")
+	var form testXMLForm
+	err := h.Decode(req, &form)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertInt(t, "n", form.N, 1)
+}
+
+func TestXMLErrorView(t *testing.T) {
+	h := New(WithEncoder(NewAcceptEncoder(map[string]Encoder{
+		"application/xml": NewXMLEncoder(),
+	})))
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+	req.Header.Set("X-Request-ID", "test")
+	h.ServeHTTP(w, req)
+	resp := w.Result()
+	assertStatus(t, resp, http.StatusNotFound)
+	assertHeader(t, resp, "Content-Type", "application/xml; charset=utf-8")
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<error><code>404</code><title>Not Found</title><request_id>test</request_id></error>`
+	assertString(t, "body", w.Body.String(), want)
+}